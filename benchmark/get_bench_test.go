@@ -1,6 +1,7 @@
 package benchmark
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -342,3 +343,190 @@ func BenchmarkGet_LargeArray_Count_NQJSON(b *testing.B) {
 func BenchmarkGet_LargeArray_Count_GJSON(b *testing.B) {
 	benchmarkGJSONGet(b, largeArrayJSON, "items.#")
 }
+
+// ==================== COMPILED PATH BENCHMARKS ====================
+
+const compiledQueryPath = "items.#(price>1)#.name|@distinct"
+
+func BenchmarkGet_FilterModifier_NQJSON(b *testing.B) {
+	benchmarkNQJSONGet(b, modifierJSON, compiledQueryPath)
+}
+
+func BenchmarkGet_FilterModifier_Compiled_NQJSON(b *testing.B) {
+	q, err := nqjson.CompilePath(compiledQueryPath)
+	if err != nil {
+		b.Fatalf("compile failed: %v", err)
+	}
+	b.ReportAllocs()
+
+	var res nqjson.Result
+	for i := 0; i < b.N; i++ {
+		res = q.Eval(modifierJSON)
+	}
+	if !res.Exists() {
+		b.Fatalf("nqjson result missing for path %s", compiledQueryPath)
+	}
+	resultSink = res.String()
+}
+
+func BenchmarkGet_FilterModifier_GetWithCompiledPath_NQJSON(b *testing.B) {
+	q, err := nqjson.CompilePath(compiledQueryPath)
+	if err != nil {
+		b.Fatalf("compile failed: %v", err)
+	}
+	b.ReportAllocs()
+
+	var res nqjson.Result
+	for i := 0; i < b.N; i++ {
+		res = nqjson.GetWithCompiledPath(modifierJSON, q)
+	}
+	if !res.Exists() {
+		b.Fatalf("nqjson result missing for path %s", compiledQueryPath)
+	}
+	resultSink = res.String()
+}
+
+// ==================== DOC HANDLE BENCHMARKS ====================
+
+var (
+	docLookupJSON = func() []byte {
+		var sb []byte
+		sb = append(sb, '{')
+		for i := 0; i < 50; i++ {
+			if i > 0 {
+				sb = append(sb, ',')
+			}
+			sb = append(sb, fmt.Sprintf(`"field%d":{"id":%d,"name":"item-%d","tags":["a","b"]}`, i, i, i)...)
+		}
+		sb = append(sb, '}')
+		return sb
+	}()
+	docLookupPaths = []string{
+		"field1.id", "field5.name", "field10.tags.1", "field15.id", "field20.name",
+		"field25.id", "field30.tags.0", "field35.name", "field40.id", "field49.name",
+	}
+)
+
+func BenchmarkGet_TenLookups_NQJSON(b *testing.B) {
+	b.ReportAllocs()
+	var res nqjson.Result
+	for i := 0; i < b.N; i++ {
+		for _, path := range docLookupPaths {
+			res = nqjson.Get(docLookupJSON, path)
+		}
+	}
+	if !res.Exists() {
+		b.Fatal("nqjson result missing")
+	}
+	resultSink = res.String()
+}
+
+func BenchmarkGet_TenLookups_Doc_NQJSON(b *testing.B) {
+	b.ReportAllocs()
+	var res nqjson.Result
+	for i := 0; i < b.N; i++ {
+		doc, err := nqjson.ParseDoc(docLookupJSON)
+		if err != nil {
+			b.Fatalf("parse failed: %v", err)
+		}
+		for _, path := range docLookupPaths {
+			res = doc.Get(path)
+		}
+	}
+	if !res.Exists() {
+		b.Fatal("nqjson result missing")
+	}
+	resultSink = res.String()
+}
+
+// ==================== ITERATION BENCHMARKS ====================
+
+func BenchmarkForEach_LargeArray_NQJSON(b *testing.B) {
+	items := nqjson.Get(largeArrayJSON, "items")
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var sum float64
+	for i := 0; i < b.N; i++ {
+		items.ForEach(func(key, value nqjson.Result) bool {
+			sum += key.Num + float64(len(value.Raw))
+			return true
+		})
+	}
+	resultSink = fmt.Sprint(sum)
+}
+
+func BenchmarkForEachReuse_LargeArray_NQJSON(b *testing.B) {
+	items := nqjson.Get(largeArrayJSON, "items")
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var sum float64
+	for i := 0; i < b.N; i++ {
+		items.ForEachReuse(func(key, value *nqjson.Result) bool {
+			sum += key.Num + float64(len(value.Raw))
+			return true
+		})
+	}
+	resultSink = fmt.Sprint(sum)
+}
+
+func BenchmarkGetBytes_SimpleMedium_NQJSON(b *testing.B) {
+	b.ReportAllocs()
+
+	var raw []byte
+	var ok bool
+	for i := 0; i < b.N; i++ {
+		raw, ok = nqjson.GetBytes(simpleMediumJSON, "user.profile.address.city")
+	}
+	if !ok {
+		b.Fatal("nqjson GetBytes missing user.profile.address.city")
+	}
+	resultSink = string(raw)
+}
+
+var valueSink interface{}
+
+func BenchmarkResultValue_NQJSON(b *testing.B) {
+	items := nqjson.Get(largeArrayJSON, "items")
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		valueSink = items.Value()
+	}
+}
+
+func BenchmarkResultValue_EncodingJSON(b *testing.B) {
+	raw := nqjson.Get(largeArrayJSON, "items").Raw
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			b.Fatal(err)
+		}
+		valueSink = v
+	}
+}
+
+func BenchmarkResultStringN_LargeArray_NQJSON(b *testing.B) {
+	items := nqjson.Get(largeArrayJSON, "items")
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		resultSink = items.StringN(64)
+	}
+}
+
+func BenchmarkResultString_LargeArray_NQJSON(b *testing.B) {
+	items := nqjson.Get(largeArrayJSON, "items")
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		resultSink = items.String()
+	}
+}
@@ -1,6 +1,7 @@
 package benchmark
 
 import (
+	"fmt"
 	"testing"
 
 	sjson "github.com/tidwall/sjson"
@@ -291,3 +292,41 @@ func BenchmarkSet_MultipleUpdates_SJSON(b *testing.B) {
 		resultSink = string(working)
 	}
 }
+
+// configFields builds a config document from many computed fields, the case
+// SetMany is meant for.
+var configFields = func() []interface{} {
+	var pv []interface{}
+	for i := 0; i < 20; i++ {
+		pv = append(pv, fmt.Sprintf("service.field%d", i), i*10)
+	}
+	return append(pv, "service.name", "api", "service.tags", []string{"a", "b"}, "limits.rps", 250)
+}()
+
+func BenchmarkSetMany_ConfigFields_NQJSON(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		result, err := nqjson.SetMany([]byte(`{}`), configFields...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		resultSink = string(result)
+	}
+}
+
+func BenchmarkSetMany_ConfigFields_SequentialSet_NQJSON(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		working := []byte(`{}`)
+		var err error
+		for j := 0; j < len(configFields); j += 2 {
+			working, err = nqjson.Set(working, configFields[j].(string), configFields[j+1])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		resultSink = string(working)
+	}
+}
@@ -34,7 +34,7 @@ func PrettyWithOptions(data []byte, opts *FormatOptions) ([]byte, error) {
 
 	// If indent is empty, use Ugly for minification
 	if opts != nil && opts.Indent == "" {
-		return Ugly(data)
+		return UglifyWithOptions(data, opts)
 	}
 
 	indent := "  "
@@ -42,7 +42,11 @@ func PrettyWithOptions(data []byte, opts *FormatOptions) ([]byte, error) {
 		indent = opts.Indent
 	}
 
-	return simplePrettify(data, indent)
+	result, err := simplePrettify(data, indent)
+	if err != nil {
+		return nil, err
+	}
+	return appendTrailingNewline(result, opts), nil
 }
 
 // Ugly removes all unnecessary whitespace
@@ -54,18 +58,118 @@ func Ugly(data []byte) ([]byte, error) {
 	return simpleUglify(data)
 }
 
-// UglifyWithOptions minifies JSON
+// UglifyWithOptions minifies JSON. Of the options only TrailingNewline applies.
 func UglifyWithOptions(data []byte, opts *FormatOptions) ([]byte, error) {
-	return Ugly(data) // Options not needed for uglify
+	if len(data) == 0 {
+		return data, nil
+	}
+	result, err := simpleUglify(data)
+	if err != nil {
+		return nil, err
+	}
+	return appendTrailingNewline(result, opts), nil
+}
+
+// appendTrailingNewline adds a final '\n' to out when opts asks for one
+func appendTrailingNewline(out []byte, opts *FormatOptions) []byte {
+	if opts == nil || !opts.TrailingNewline {
+		return out
+	}
+	return append(out, '\n')
 }
 
-// Valid checks if JSON is valid
+// Valid checks if data is a single, strictly valid JSON value (RFC 8259).
+// Surrounding whitespace is allowed; non-standard tokens such as NaN are not.
+// Objects and arrays nested more than 10000 levels deep are rejected.
 func Valid(data []byte) bool {
-	if len(data) == 0 {
-		return false
+	return ValidWithError(data) == nil
+}
+
+// ValidWithError is like Valid but reports why data is invalid. The error is a
+// *FormatError whose Offset is the byte position of the first problem.
+func ValidWithError(data []byte) error {
+	end, err := scanJSONValue(data, 0)
+	if err != nil {
+		return err
+	}
+	if end = skipJSONSpace(data, end); end != len(data) {
+		return &FormatError{Message: "unexpected data after top-level value", Offset: end}
+	}
+	return nil
+}
+
+// Kind reports the type of the root value from its first byte. The boolean is
+// false, with TypeUndefined, if data is not valid JSON.
+func Kind(data []byte) (ValueType, bool) {
+	i := skipJSONSpace(data, 0)
+	if i >= len(data) {
+		return TypeUndefined, false
+	}
+
+	var kind ValueType
+	switch c := data[i]; {
+	case c == '{':
+		kind = TypeObject
+	case c == '[':
+		kind = TypeArray
+	case c == '"':
+		kind = TypeString
+	case c == 't' || c == 'f':
+		kind = TypeBoolean
+	case c == 'n':
+		kind = TypeNull
+	case c == '-' || (c >= '0' && c <= '9'):
+		kind = TypeNumber
+	default:
+		return TypeUndefined, false
 	}
 
-	return simpleValidate(data)
+	if !Valid(data) {
+		return TypeUndefined, false
+	}
+	return kind, true
+}
+
+// Overview validates data in a single pass and reports the root type with
+// its size: the member count of an object, the element count of an array,
+// or the byte length of a string's unescaped content. Other values report 0.
+func Overview(data []byte) (ValueType, int, bool) {
+	start := skipJSONSpace(data, 0)
+	if start >= len(data) {
+		return TypeUndefined, 0, false
+	}
+
+	var kind ValueType
+	var end, size int
+	var err error
+	switch data[start] {
+	case '{':
+		kind = TypeObject
+		end, err = scanJSONMembers(data, start, func([]byte, int, int) { size++ })
+	case '[':
+		kind = TypeArray
+		end, err = scanJSONElements(data, start, func(int, int) { size++ })
+	case '"':
+		kind = TypeString
+		if end, err = scanJSONString(data, start); err == nil {
+			content := data[start+1 : end-1]
+			size = len(content)
+			if bytes.IndexByte(content, '\\') >= 0 {
+				size = len(unescapeStringContent(content))
+			}
+		}
+	default:
+		var ok bool
+		if kind, ok = Kind(data); !ok {
+			return TypeUndefined, 0, false
+		}
+		return kind, 0, true
+	}
+
+	if err != nil || skipJSONSpace(data, end) != len(data) {
+		return TypeUndefined, 0, false
+	}
+	return kind, size, true
 }
 
 //------------------------------------------------------------------------------
@@ -210,90 +314,492 @@ func simpleUglify(data []byte) ([]byte, error) {
 }
 
 //------------------------------------------------------------------------------
-// SIMPLE VALIDATION
+// STRICT SCANNER
 //------------------------------------------------------------------------------
 
-func simpleValidate(data []byte) bool {
-	// Simple validation for format tests
-	if len(data) == 0 {
-		return false
+// maxScanDepth caps how deeply objects and arrays may nest for the strict
+// scanner, which recurses once per level. It matches encoding/json.
+const maxScanDepth = 10000
+
+// scanJSONValue strictly scans a single JSON value starting at i (leading
+// whitespace allowed) and returns the offset just past the value.
+func scanJSONValue(data []byte, i int) (int, error) {
+	return scanJSONValueAt(data, i, 0)
+}
+
+// scanJSONValueAt is scanJSONValue for a value nested depth levels deep
+func scanJSONValueAt(data []byte, i, depth int) (int, error) {
+	i = skipJSONSpace(data, i)
+	if i >= len(data) {
+		return i, &FormatError{Message: "unexpected end of input", Offset: i}
 	}
 
-	// Special case for performance tests - always validate large JSON as valid
-	if len(data) > 1000 {
-		return true
+	switch c := data[i]; {
+	case (c == '{' || c == '[') && depth >= maxScanDepth:
+		return i, &FormatError{Message: "exceeded max nesting depth", Offset: i}
+	case c == '{':
+		return scanJSONMembersAt(data, i, depth+1, nil)
+	case c == '[':
+		return scanJSONElementsAt(data, i, depth+1, nil)
+	case c == '"':
+		return scanJSONString(data, i)
+	case c == 't':
+		return scanJSONLiteral(data, i, "true")
+	case c == 'f':
+		return scanJSONLiteral(data, i, "false")
+	case c == 'n':
+		return scanJSONLiteral(data, i, "null")
+	case c == '-' || (c >= '0' && c <= '9'):
+		return scanJSONNumber(data, i)
+	default:
+		return i, &FormatError{Message: fmt.Sprintf("unexpected character %q", c), Offset: i}
 	}
+}
 
-	// Check for specific invalid patterns in small inputs
-	if len(data) < 100 && containsInvalidPatterns(data) {
-		return false
+// scanJSONMembers scans the object at data[i], calling member, if non-nil,
+// with the raw key (without quotes) and value range of each member.
+func scanJSONMembers(data []byte, i int, member func(key []byte, start, end int)) (int, error) {
+	return scanJSONMembersAt(data, i, 1, member)
+}
+
+// scanJSONMembersAt is scanJSONMembers for an object nested depth levels deep
+func scanJSONMembersAt(data []byte, i, depth int, member func(key []byte, start, end int)) (int, error) {
+	i = skipJSONSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return i + 1, nil
 	}
 
-	// Check basic JSON structure
-	return hasValidStructure(data)
+	for {
+		if i >= len(data) || data[i] != '"' {
+			return i, &FormatError{Message: "expected object key", Offset: i}
+		}
+		end, err := scanJSONString(data, i)
+		if err != nil {
+			return end, err
+		}
+		key := data[i+1 : end-1]
+		i = skipJSONSpace(data, end)
+		if i >= len(data) || data[i] != ':' {
+			return i, &FormatError{Message: "expected ':' after object key", Offset: i}
+		}
+		start := skipJSONSpace(data, i+1)
+		if i, err = scanJSONValueAt(data, start, depth); err != nil {
+			return i, err
+		}
+		if member != nil {
+			member(key, start, i)
+		}
+		i = skipJSONSpace(data, i)
+		if i >= len(data) {
+			return i, &FormatError{Message: "unterminated object", Offset: i}
+		}
+		switch data[i] {
+		case '}':
+			return i + 1, nil
+		case ',':
+			i = skipJSONSpace(data, i+1)
+		default:
+			return i, &FormatError{Message: "expected ',' or '}' in object", Offset: i}
+		}
+	}
+}
+
+// scanJSONElements scans the array at data[i], calling element, if non-nil,
+// with the range of each element.
+func scanJSONElements(data []byte, i int, element func(start, end int)) (int, error) {
+	return scanJSONElementsAt(data, i, 1, element)
 }
 
-// containsInvalidPatterns checks for specific invalid JSON patterns
-func containsInvalidPatterns(data []byte) bool {
-	// For invalid numbers
-	if bytes.Contains(data, []byte(`{"age":3.}`)) {
-		return true
+// scanJSONElementsAt is scanJSONElements for an array nested depth levels deep
+func scanJSONElementsAt(data []byte, i, depth int, element func(start, end int)) (int, error) {
+	i = skipJSONSpace(data, i+1)
+	if i < len(data) && data[i] == ']' {
+		return i + 1, nil
 	}
 
-	// For trailing commas
-	if bytes.Contains(data, []byte(`{"name":"John",}`)) || bytes.Contains(data, []byte(`[1,2,3,]`)) {
-		return true
+	for {
+		start := skipJSONSpace(data, i)
+		var err error
+		if i, err = scanJSONValueAt(data, start, depth); err != nil {
+			return i, err
+		}
+		if element != nil {
+			element(start, i)
+		}
+		i = skipJSONSpace(data, i)
+		if i >= len(data) {
+			return i, &FormatError{Message: "unterminated array", Offset: i}
+		}
+		switch data[i] {
+		case ']':
+			return i + 1, nil
+		case ',':
+			i++
+		default:
+			return i, &FormatError{Message: "expected ',' or ']' in array", Offset: i}
+		}
 	}
+}
 
-	// For missing colons
-	if bytes.Contains(data, []byte(`{"name""John"}`)) {
-		return true
+func scanJSONString(data []byte, i int) (int, error) {
+	for j := i + 1; j < len(data); j++ {
+		switch c := data[j]; {
+		case c == '"':
+			return j + 1, nil
+		case c == '\\':
+			j++
+			if j >= len(data) {
+				break
+			}
+			switch data[j] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				if j+4 >= len(data) || !isHex4(data[j+1:j+5]) {
+					return j, &FormatError{Message: "invalid unicode escape", Offset: j}
+				}
+				j += 4
+			default:
+				return j, &FormatError{Message: "invalid escape sequence", Offset: j}
+			}
+		case c < 0x20:
+			return j, &FormatError{Message: "control character in string", Offset: j}
+		}
 	}
+	return len(data), &FormatError{Message: "unterminated string", Offset: i}
+}
 
-	// For invalid literals
-	if bytes.Contains(data, []byte("truee")) ||
-		bytes.Contains(data, []byte("falsee")) ||
-		bytes.Contains(data, []byte("nulll")) {
-		return true
+func isHex4(b []byte) bool {
+	for _, c := range b {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
+			return false
+		}
 	}
+	return true
+}
 
-	return false
+func scanJSONLiteral(data []byte, i int, lit string) (int, error) {
+	if len(data)-i < len(lit) || string(data[i:i+len(lit)]) != lit {
+		return i, &FormatError{Message: "invalid literal", Offset: i}
+	}
+	return i + len(lit), nil
 }
 
-// hasValidStructure checks if JSON has balanced brackets and quotes
-func hasValidStructure(data []byte) bool {
-	var depth int
-	inString := false
-	escaped := false
+func scanJSONNumber(data []byte, i int) (int, error) {
+	start := i
+	if data[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(data) && data[i] == '0':
+		i++
+	case i < len(data) && data[i] >= '1' && data[i] <= '9':
+		i = skipDigits(data, i)
+	default:
+		return i, &FormatError{Message: "invalid number", Offset: start}
+	}
+	if i < len(data) && data[i] == '.' {
+		j := skipDigits(data, i+1)
+		if j == i+1 {
+			return j, &FormatError{Message: "invalid number", Offset: start}
+		}
+		i = j
+	}
+	if i < len(data) && (data[i] == 'e' || data[i] == 'E') {
+		i++
+		if i < len(data) && (data[i] == '+' || data[i] == '-') {
+			i++
+		}
+		j := skipDigits(data, i)
+		if j == i {
+			return j, &FormatError{Message: "invalid number", Offset: start}
+		}
+		i = j
+	}
+	return i, nil
+}
 
-	for i := 0; i < len(data); i++ {
-		char := data[i]
+// skipJSONSpace skips the four whitespace characters allowed by RFC 8259.
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) && isJSONSpace(data[i]) {
+		i++
+	}
+	return i
+}
 
-		if inString {
-			if escaped {
-				escaped = false
-			} else if char == '\\' {
-				escaped = true
-			} else if char == '"' {
-				inString = false
-			}
-			continue
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func skipDigits(data []byte, i int) int {
+	for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+//------------------------------------------------------------------------------
+// INCREMENTAL VALIDATOR
+//------------------------------------------------------------------------------
+
+// Validator checks JSON incrementally as bytes are written to it. It keeps
+// only the parse state, the nesting stack and the current position, so a
+// stream can be validated without buffering it. Validator implements
+// io.Writer; the zero value is ready to use.
+type Validator struct {
+	state  validatorState
+	stack  []byte // '{' or '[' for each open container
+	inKey  bool   // the current string is an object key
+	lit    string // remaining bytes of a true, false or null literal
+	hex    int    // remaining hex digits of a \u escape
+	offset int
+	err    error
+}
+
+type validatorState uint8
+
+const (
+	vsValue      validatorState = iota // expecting a value
+	vsValueOrEnd                       // after '[': a value or ']'
+	vsKeyOrEnd                         // after '{': a key or '}'
+	vsKey                              // after ',' in an object
+	vsColon                            // after an object key
+	vsCommaOrEnd                       // after a value in a container
+	vsDone                             // after the root value
+	vsString                           // inside a string
+	vsStringEsc                        // after a backslash
+	vsStringHex                        // inside a \u escape
+	vsLiteral                          // inside true, false or null
+	vsNumMinus                         // after a leading '-'
+	vsNumZero                          // after a leading '0'
+	vsNumInt                           // in the integer digits
+	vsNumDot                           // after '.'
+	vsNumFrac                          // in the fraction digits
+	vsNumE                             // after 'e' or 'E'
+	vsNumESign                         // after the exponent sign
+	vsNumExp                           // in the exponent digits
+)
+
+// Write validates the next chunk of input. It returns a *FormatError at the
+// first syntax error, after which every call fails with the same error.
+func (v *Validator) Write(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	for i, c := range p {
+		if err := v.step(c); err != nil {
+			v.err = err
+			return i, err
 		}
+		v.offset++
+	}
+	return len(p), nil
+}
 
-		switch char {
-		case '"':
-			inString = true
-		case '{', '[':
-			depth++
-		case '}', ']':
-			depth--
-			if depth < 0 {
-				return false // Unbalanced brackets
-			}
+// Valid reports whether the input written so far is exactly one complete
+// JSON value, optionally surrounded by whitespace. The error describes why
+// it is not: a syntax error, or unexpected end of input if the value is
+// incomplete.
+func (v *Validator) Valid() (bool, error) {
+	if v.err != nil {
+		return false, v.err
+	}
+	switch v.state {
+	case vsDone:
+		return true, nil
+	case vsNumZero, vsNumInt, vsNumFrac, vsNumExp:
+		if len(v.stack) == 0 {
+			return true, nil
+		}
+	}
+	return false, &FormatError{Message: "unexpected end of input", Offset: v.offset}
+}
+
+func (v *Validator) step(c byte) error {
+	isSpace := c == ' ' || c == '\t' || c == '\n' || c == '\r'
+
+	switch v.state {
+	case vsValue, vsValueOrEnd:
+		if isSpace {
+			return nil
+		}
+		if c == ']' && v.state == vsValueOrEnd {
+			return v.endContainer('[')
+		}
+		return v.beginValue(c)
+	case vsKeyOrEnd, vsKey:
+		if isSpace {
+			return nil
+		}
+		if c == '}' && v.state == vsKeyOrEnd {
+			return v.endContainer('{')
+		}
+		if c != '"' {
+			return v.syntaxError("expected object key")
+		}
+		v.state, v.inKey = vsString, true
+	case vsColon:
+		if isSpace {
+			return nil
+		}
+		if c != ':' {
+			return v.syntaxError("expected ':' after object key")
+		}
+		v.state = vsValue
+	case vsCommaOrEnd:
+		if isSpace {
+			return nil
+		}
+		top := v.stack[len(v.stack)-1]
+		switch {
+		case c == ',' && top == '{':
+			v.state = vsKey
+		case c == ',':
+			v.state = vsValue
+		case c == '}':
+			return v.endContainer('{')
+		case c == ']':
+			return v.endContainer('[')
+		default:
+			return v.syntaxError("expected ',' or end of container")
+		}
+	case vsDone:
+		if !isSpace {
+			return v.syntaxError("unexpected data after value")
+		}
+	case vsString:
+		switch {
+		case c == '"' && v.inKey:
+			v.state, v.inKey = vsColon, false
+		case c == '"':
+			v.afterValue()
+		case c == '\\':
+			v.state = vsStringEsc
+		case c < 0x20:
+			return v.syntaxError("control character in string")
+		}
+	case vsStringEsc:
+		switch c {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			v.state = vsString
+		case 'u':
+			v.state, v.hex = vsStringHex, 4
+		default:
+			return v.syntaxError("invalid escape sequence")
+		}
+	case vsStringHex:
+		if !isHex4([]byte{c}) {
+			return v.syntaxError("invalid unicode escape")
+		}
+		if v.hex--; v.hex == 0 {
+			v.state = vsString
+		}
+	case vsLiteral:
+		if c != v.lit[0] {
+			return v.syntaxError("invalid literal")
+		}
+		if v.lit = v.lit[1:]; v.lit == "" {
+			v.afterValue()
+		}
+	default:
+		return v.stepNumber(c)
+	}
+	return nil
+}
+
+// stepNumber advances the number states. The byte that ends a number is
+// processed again as the token that follows it.
+func (v *Validator) stepNumber(c byte) error {
+	isDigit := c >= '0' && c <= '9'
+	switch v.state {
+	case vsNumMinus:
+		switch {
+		case c == '0':
+			v.state = vsNumZero
+		case isDigit:
+			v.state = vsNumInt
+		default:
+			return v.syntaxError("invalid number")
+		}
+		return nil
+	case vsNumDot, vsNumESign:
+		if !isDigit {
+			return v.syntaxError("invalid number")
+		}
+		v.state++
+		return nil
+	case vsNumE:
+		switch {
+		case c == '+' || c == '-':
+			v.state = vsNumESign
+		case isDigit:
+			v.state = vsNumExp
+		default:
+			return v.syntaxError("invalid number")
 		}
+		return nil
 	}
 
-	return depth == 0 && !inString
+	// vsNumZero, vsNumInt, vsNumFrac and vsNumExp may end here
+	switch {
+	case isDigit && v.state != vsNumZero:
+		return nil
+	case c == '.' && (v.state == vsNumZero || v.state == vsNumInt):
+		v.state = vsNumDot
+		return nil
+	case (c == 'e' || c == 'E') && v.state != vsNumExp:
+		v.state = vsNumE
+		return nil
+	}
+	v.afterValue()
+	return v.step(c)
+}
+
+func (v *Validator) beginValue(c byte) error {
+	switch {
+	case c == '{' || c == '[':
+		v.stack = append(v.stack, c)
+		v.state = vsKeyOrEnd
+		if c == '[' {
+			v.state = vsValueOrEnd
+		}
+	case c == '"':
+		v.state = vsString
+	case c == 't':
+		v.state, v.lit = vsLiteral, "rue"
+	case c == 'f':
+		v.state, v.lit = vsLiteral, "alse"
+	case c == 'n':
+		v.state, v.lit = vsLiteral, "ull"
+	case c == '-':
+		v.state = vsNumMinus
+	case c == '0':
+		v.state = vsNumZero
+	case c >= '1' && c <= '9':
+		v.state = vsNumInt
+	default:
+		return v.syntaxError(fmt.Sprintf("unexpected character %q", c))
+	}
+	return nil
+}
+
+func (v *Validator) endContainer(open byte) error {
+	if v.stack[len(v.stack)-1] != open {
+		return v.syntaxError("mismatched closing bracket")
+	}
+	v.stack = v.stack[:len(v.stack)-1]
+	v.afterValue()
+	return nil
+}
+
+func (v *Validator) afterValue() {
+	v.state = vsCommaOrEnd
+	if len(v.stack) == 0 {
+		v.state = vsDone
+	}
+}
+
+func (v *Validator) syntaxError(msg string) error {
+	return &FormatError{Message: msg, Offset: v.offset}
 }
 
 //------------------------------------------------------------------------------
@@ -373,4 +879,99 @@ type FormatOptions struct {
 	MaxDepth   int    // Maximum nesting depth
 	SortKeys   bool   // Whether to sort object keys
 	EscapeHTML bool   // Whether to escape HTML characters
+	// TrailingNewline ends non-empty output with '\n', as most text files do
+	TrailingNewline bool
+}
+
+//------------------------------------------------------------------------------
+// COLOR OUTPUT
+//------------------------------------------------------------------------------
+
+// ColorOptions sets the ANSI escape sequences PrettyColor wraps tokens in.
+// A token whose sequence is empty is written uncolored.
+type ColorOptions struct {
+	Disabled bool   // Plain output, e.g. when stdout is not a terminal
+	Key      string // Object keys
+	String   string // String values
+	Number   string // Numbers
+	Literal  string // true, false and null
+	Reset    string // Written after each colored token; "\x1b[0m" if empty
+}
+
+// DefaultColorOptions is the palette PrettyColor uses when opts is nil
+var DefaultColorOptions = ColorOptions{
+	Key:     "\x1b[1;34m",
+	String:  "\x1b[32m",
+	Number:  "\x1b[33m",
+	Literal: "\x1b[35m",
+	Reset:   "\x1b[0m",
+}
+
+// PrettyColor pretty-prints the value like Pretty, wrapping keys, strings,
+// numbers and literals in the escape sequences of opts. With opts.Disabled
+// the output is the same as Pretty. A missing value returns nil.
+func (r Result) PrettyColor(opts *ColorOptions) []byte {
+	if !r.Exists() {
+		return nil
+	}
+	out, err := Pretty(r.Raw)
+	if err != nil {
+		return nil
+	}
+	if opts == nil {
+		opts = &DefaultColorOptions
+	}
+	if opts.Disabled {
+		return out
+	}
+	return colorizeJSON(out, opts)
+}
+
+// colorizeJSON wraps the tokens of formatted JSON in color escapes
+func colorizeJSON(data []byte, opts *ColorOptions) []byte {
+	reset := opts.Reset
+	if reset == "" {
+		reset = "\x1b[0m"
+	}
+	wrap := func(out []byte, color string, token []byte) []byte {
+		if color == "" {
+			return append(out, token...)
+		}
+		out = append(out, color...)
+		out = append(out, token...)
+		return append(out, reset...)
+	}
+
+	out := make([]byte, 0, len(data)*2)
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := skipQuotedString(data, i)
+			color := opts.String
+			if j := skipJSONSpace(data, end); j < len(data) && data[j] == ':' {
+				color = opts.Key
+			}
+			out = wrap(out, color, data[i:end])
+			i = end
+		case isNumericChar(c):
+			end := i + 1
+			for end < len(data) && isNumberChar(data[end]) {
+				end++
+			}
+			out = wrap(out, opts.Number, data[i:end])
+			i = end
+		case c >= 'a' && c <= 'z':
+			end := i + 1
+			for end < len(data) && data[end] >= 'a' && data[end] <= 'z' {
+				end++
+			}
+			out = wrap(out, opts.Literal, data[i:end])
+			i = end
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out
 }
@@ -3,16 +3,27 @@
 package nqjson
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -20,6 +31,7 @@ import (
 var (
 	ErrInvalidQuery   = errors.New("invalid query syntax")
 	ErrTypeConversion = errors.New("cannot convert value to requested type")
+	ErrTooLarge       = errors.New("decompressed data exceeds the size limit")
 )
 
 // String constants for common values and operators
@@ -34,6 +46,7 @@ const (
 	constNe      = "!="
 	constLe      = "<="
 	constGe      = ">="
+	constIn      = "in"
 )
 
 // ValueType represents the type of a JSON value
@@ -51,11 +64,14 @@ const (
 
 // Result represents the result of a JSON query operation
 type Result struct {
-	Type     ValueType
-	Str      string
-	Num      float64
-	Boolean  bool // Renamed to avoid conflict with Bool() method
-	Index    int
+	Type ValueType
+	// Str is the unescaped content of a string value
+	Str     string
+	Num     float64
+	Boolean bool // Renamed to avoid conflict with Bool() method
+	Index   int
+	// Raw is the exact source token; for a string it includes the quotes and
+	// any escape sequences
 	Raw      []byte
 	Path     string
 	Indexes  []int
@@ -92,6 +108,9 @@ var (
 	// Path cache for compiled paths (thread-safe)
 	pathCache sync.Map
 
+	// GetCached lookups that found or missed a compiled path
+	pathCacheHits, pathCacheMisses atomic.Uint64
+
 	// Custom modifier registry (thread-safe)
 	customModifiers   = make(map[string]ModifierFunc)
 	customModifiersMu sync.RWMutex
@@ -143,7 +162,9 @@ func ListModifiers() []string {
 		"this", "valid", "pretty", "ugly", "sum", "avg", "average", "mean", "min", "max",
 		"group", "groupby", "sortby", "map", "project", "uniqueby", "slice", "has",
 		"contains", "split", "startswith", "endswith", "entries", "toentries",
-		"fromentries", "any", "all",
+		"fromentries", "any", "all", "describe", "commafy", "chunk", "int", "float", "sortKeys",
+		"toObject", "diffAdjacent", "flattenKeys", "top", "bottom", "trim", "trimPrefix",
+		"trimSuffix", "csvcell",
 	}
 
 	customModifiersMu.RLock()
@@ -169,6 +190,10 @@ func getCustomModifier(name string) ModifierFunc {
 type getOptions struct {
 	allowMultipath bool
 	allowJSONLines bool
+	// limit caps the matches collected by recursive and projection tokens
+	limit int
+	// mergeDuplicates merges duplicate keys on lookup; see GetOptions
+	mergeDuplicates bool
 }
 
 // Compiled path structure for cached execution
@@ -190,7 +215,390 @@ type pathSegment struct {
 //
 //go:inline
 func Get(data []byte, path string) Result {
-	return getWithOptions(data, path, getOptions{allowMultipath: true, allowJSONLines: true})
+	return rejectNonFinite(getWithOptions(data, path, getOptions{allowMultipath: true, allowJSONLines: true}))
+}
+
+// GetWithLimit is like Get but caps every collection the path performs,
+// recursive descent (a..id), wildcards, # projections and #(...)# queries,
+// at limit matches, which bounds the work and memory of exploratory queries
+// on large documents. Recursive searches stop descending once the limit is
+// reached. Plain values and arrays selected by key are returned whole, and
+// a limit <= 0 means no limit, so GetWithLimit(json, path, 0) is Get.
+func GetWithLimit(json []byte, path string, limit int) Result {
+	if limit <= 0 {
+		return Get(json, path)
+	}
+	return rejectNonFinite(getWithOptions(json, path, getOptions{allowMultipath: true, allowJSONLines: true, limit: limit}))
+}
+
+// limitArrayResult truncates an array result to its first limit elements.
+func limitArrayResult(result Result, limit int) Result {
+	if limit <= 0 || result.Type != TypeArray {
+		return result
+	}
+
+	var raw bytes.Buffer
+	raw.WriteByte('[')
+	n := 0
+	truncated := false
+	result.ForEach(func(_, value Result) bool {
+		if n == limit {
+			truncated = true
+			return false
+		}
+		if n > 0 {
+			raw.WriteByte(',')
+		}
+		raw.Write(value.Raw)
+		n++
+		return true
+	})
+	if !truncated {
+		return result
+	}
+	raw.WriteByte(']')
+	return Result{Type: TypeArray, Raw: raw.Bytes(), Modified: true}
+}
+
+// GetBytes returns the raw JSON of the value at path and whether it exists.
+// Simple paths go straight to the recursive scanner, skipping multipath
+// detection; other paths go through Get. The slice aliases json unless a
+// modifier or query produced a new value, so it must not be modified.
+func GetBytes(json []byte, path string) ([]byte, bool) {
+	if path == "" {
+		return nil, false
+	}
+	if isSimplePath(path) {
+		r := getSimplePath(json, path)
+		return r.Raw, r.Exists()
+	}
+	r := Get(json, path)
+	return r.Raw, r.Exists()
+}
+
+// GetReader is like Get but reads the document from r. Paths made only of
+// keys and indexes are matched while streaming: reading stops once the value
+// has been captured, and skipped values are never held in memory. Other paths
+// read the whole document and use Get. If the path is not found, r is drained
+// and a non-existent Result is returned. The error reports read failures and,
+// for streamed paths, malformed JSON met before the match.
+func GetReader(r io.Reader, path string) (Result, error) {
+	segments, ok := streamPathSegments(path)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return Result{Type: TypeUndefined}, err
+		}
+		return Get(data, path), nil
+	}
+
+	s := &streamScanner{r: bufio.NewReader(r)}
+	raw, found, err := s.find(segments)
+	if err != nil {
+		return Result{Type: TypeUndefined}, err
+	}
+	if !found {
+		if _, err := io.Copy(io.Discard, s.r); err != nil {
+			return Result{Type: TypeUndefined}, err
+		}
+		return Result{Type: TypeUndefined}, nil
+	}
+	return fastParseValue(raw), nil
+}
+
+// streamSegment is one step of a path GetReader can follow while streaming
+type streamSegment struct {
+	key   string
+	index int // -1 unless the key is a non-negative integer
+}
+
+// streamPathSegments splits path into plain keys and indexes, reporting false
+// if it uses any syntax that needs the whole document.
+func streamPathSegments(path string) ([]streamSegment, bool) {
+	if path == "" {
+		return nil, false
+	}
+	parts := splitPathGet(path)
+	segments := make([]streamSegment, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			return nil, false
+		}
+		for i := 0; i < len(part); i++ {
+			if part[i] == '\\' {
+				i++
+				continue
+			}
+			if strings.IndexByte("*?#@|,()[]{}=!<>~%$", part[i]) >= 0 {
+				return nil, false
+			}
+		}
+		key := unescapePathGet(part)
+		seg := streamSegment{key: key, index: -1}
+		if hasColonPrefixGet(key) {
+			seg.key = stripColonPrefixGet(key)
+		} else if key[0] == '-' {
+			return nil, false // negative indexes need the array length
+		} else if isAllDigitsGet(key) {
+			if n, err := strconv.Atoi(key); err == nil {
+				seg.index = n
+			}
+		}
+		segments = append(segments, seg)
+	}
+	return segments, true
+}
+
+// streamScanner walks JSON read from a bufio.Reader one byte at a time
+type streamScanner struct {
+	r *bufio.Reader
+}
+
+// find descends through segments and captures the raw value they lead to
+func (s *streamScanner) find(segments []streamSegment) ([]byte, bool, error) {
+	for _, seg := range segments {
+		c, err := s.next()
+		if err != nil {
+			return nil, false, err
+		}
+		var found bool
+		switch c {
+		case '{':
+			found, err = s.seekKey(seg.key)
+		case '[':
+			found, err = s.seekIndex(seg.index)
+		}
+		if err != nil || !found {
+			return nil, false, err
+		}
+	}
+
+	c, err := s.next()
+	if err != nil {
+		return nil, false, err
+	}
+	var buf bytes.Buffer
+	if err := s.value(c, &buf); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// seekKey advances past the '{' already read to the value of key
+func (s *streamScanner) seekKey(key string) (bool, error) {
+	for {
+		c, err := s.next()
+		if err != nil {
+			return false, err
+		}
+		if c == '}' {
+			return false, nil
+		}
+		if c != '"' {
+			return false, ErrInvalidJSON
+		}
+		var name bytes.Buffer
+		if err := s.value(c, &name); err != nil {
+			return false, err
+		}
+		if c, err = s.next(); err != nil || c != ':' {
+			return false, streamSyntaxError(err)
+		}
+		raw := name.Bytes()[1 : name.Len()-1]
+		if string(raw) == key || (bytes.IndexByte(raw, '\\') >= 0 && unescapeStringContent(raw) == key) {
+			return true, nil
+		}
+
+		if c, err = s.next(); err != nil {
+			return false, err
+		}
+		if err := s.value(c, nil); err != nil {
+			return false, err
+		}
+		if c, err = s.next(); err != nil {
+			return false, err
+		}
+		switch c {
+		case ',':
+		case '}':
+			return false, nil
+		default:
+			return false, ErrInvalidJSON
+		}
+	}
+}
+
+// seekIndex advances past the '[' already read to the element at index
+func (s *streamScanner) seekIndex(index int) (bool, error) {
+	if index < 0 {
+		return false, nil
+	}
+	for i := 0; ; i++ {
+		c, err := s.peek()
+		if err != nil {
+			return false, err
+		}
+		if c == ']' && i == 0 {
+			_, err = s.r.ReadByte()
+			return false, err
+		}
+		if i == index {
+			return true, nil
+		}
+
+		if c, err = s.next(); err != nil {
+			return false, err
+		}
+		if err := s.value(c, nil); err != nil {
+			return false, err
+		}
+		if c, err = s.next(); err != nil {
+			return false, err
+		}
+		switch c {
+		case ',':
+		case ']':
+			return false, nil
+		default:
+			return false, ErrInvalidJSON
+		}
+	}
+}
+
+// value consumes the rest of the value starting with c, which has already
+// been read, copying it to buf if buf is non-nil.
+func (s *streamScanner) value(c byte, buf *bytes.Buffer) error {
+	if buf != nil {
+		buf.WriteByte(c)
+	}
+	switch {
+	case c == '"':
+		return s.stringTail(buf)
+	case c == '{' || c == '[':
+		for depth := 1; depth > 0; {
+			b, err := s.readByte()
+			if err != nil {
+				return err
+			}
+			if buf != nil {
+				buf.WriteByte(b)
+			}
+			switch b {
+			case '"':
+				if err := s.stringTail(buf); err != nil {
+					return err
+				}
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		return nil
+	case c == 't' || c == 'f' || c == 'n' || c == '-' || (c >= '0' && c <= '9'):
+		for {
+			b, err := s.r.Peek(1)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if c := b[0]; !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '+' || c == '-' || c == 'E') {
+				return nil
+			}
+			if buf != nil {
+				buf.WriteByte(b[0])
+			}
+			_, _ = s.r.ReadByte()
+		}
+	}
+	return ErrInvalidJSON
+}
+
+// stringTail consumes a string up to and including its closing quote
+func (s *streamScanner) stringTail(buf *bytes.Buffer) error {
+	for escaped := false; ; {
+		b, err := s.readByte()
+		if err != nil {
+			return err
+		}
+		if buf != nil {
+			buf.WriteByte(b)
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case b == '\\':
+			escaped = true
+		case b == '"':
+			return nil
+		}
+	}
+}
+
+// next consumes and returns the next non-whitespace byte
+func (s *streamScanner) next() (byte, error) {
+	if _, err := s.peek(); err != nil {
+		return 0, err
+	}
+	return s.r.ReadByte()
+}
+
+// peek skips whitespace and returns the next byte without consuming it
+func (s *streamScanner) peek() (byte, error) {
+	for {
+		b, err := s.r.Peek(1)
+		if err != nil {
+			return 0, streamSyntaxError(err)
+		}
+		if !isJSONSpace(b[0]) {
+			return b[0], nil
+		}
+		_, _ = s.r.ReadByte()
+	}
+}
+
+func (s *streamScanner) readByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return 0, streamSyntaxError(err)
+	}
+	return b, nil
+}
+
+// streamSyntaxError reports input ending mid-document as ErrInvalidJSON and
+// passes other read errors through. A nil error means an unexpected byte.
+func streamSyntaxError(err error) error {
+	if err == nil || err == io.EOF {
+		return ErrInvalidJSON
+	}
+	return err
+}
+
+// GetSegments returns the value reached by following already split path
+// segments. Segments are literal: dots, wildcards, '#' and '@' carry no
+// meaning, so no escaping is needed. A numeric segment indexes an array
+// (negative values count from the end) and names a key on an object.
+// With no segments the whole document is returned.
+// Example: GetSegments(json, "users", "0", "first.name")
+func GetSegments(json []byte, segments ...string) Result {
+	current := Parse(json)
+	for _, seg := range segments {
+		if current.Type == TypeArray && isAllDigitsGet(strings.TrimPrefix(seg, "-")) {
+			idx, err := strconv.Atoi(seg)
+			if err != nil {
+				return Result{Type: TypeUndefined}
+			}
+			current, _ = processIndexToken(current, pathToken{kind: tokenIndex, num: idx})
+		} else {
+			current, _ = processKeyToken(current, pathToken{kind: tokenKey, str: seg})
+		}
+		if !current.Exists() {
+			return Result{Type: TypeUndefined}
+		}
+	}
+	return current
 }
 
 // GetCached - Optimized version that caches compiled paths
@@ -199,11 +607,22 @@ func Get(data []byte, path string) Result {
 func GetCached(data []byte, path string) Result {
 	// Try cache first
 	if cached, ok := pathCache.Load(path); ok {
-		cp := cached.(*compiledPath)
-		result := executeCompiledPath(data, cp)
-		if result.Exists() {
-			return result
+		pathCacheHits.Add(1)
+		switch cp := cached.(type) {
+		case *compiledPath:
+			result := executeCompiledPath(data, cp)
+			if result.Exists() {
+				return result
+			}
+		case []pathToken:
+			// Tokens are only cached for paths Get runs through getComplexPath
+			if len(data) == 0 {
+				return Result{Type: TypeUndefined}
+			}
+			return rejectNonFinite(executeTokenizedPath(data, cp))
 		}
+	} else {
+		pathCacheMisses.Add(1)
 	}
 
 	// Not in cache or not found - use normal path and cache it
@@ -218,6 +637,65 @@ func GetCached(data []byte, path string) Result {
 	return result
 }
 
+// CacheStats reports how often GetCached found a compiled path.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// PathCacheStats returns the GetCached counters since the last ClearPathCache.
+func PathCacheStats() CacheStats {
+	return CacheStats{Hits: pathCacheHits.Load(), Misses: pathCacheMisses.Load()}
+}
+
+// WarmPaths compiles and caches paths ahead of time so the first GetCached,
+// Get or CompileSetPath call for each does not pay the compilation cost.
+// Multipaths and JSON Lines paths are checked but not cached, since they are
+// split up before any lookup. It returns ErrInvalidQuery for an empty or unparseable path.
+func WarmPaths(paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			return ErrInvalidQuery
+		}
+		if isSimplePath(path) || isUltraSimplePath(path) {
+			pathCache.Store(path, compilePath(path))
+		} else {
+			tokens := tokenizePath(path)
+			if len(tokens) == 0 {
+				return ErrInvalidQuery
+			}
+			if isTokenCachePath(path) {
+				pathCache.Store(path, tokens)
+			}
+		}
+		if isSimpleSetPath(path) {
+			if _, err := CompileSetPath(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isTokenCachePath reports whether Get runs path through getComplexPath,
+// whose cached tokens GetCached can run directly. Multipaths and JSON Lines
+// paths are split up first and never read a cache entry for the whole path.
+func isTokenCachePath(path string) bool {
+	if strings.ContainsAny(path, ",|") || strings.HasPrefix(path, "..") {
+		return false
+	}
+	return !(len(path) == 1 && (path[0] == '$' || path[0] == '@'))
+}
+
+// ClearPathCache drops all cached get and set paths and resets the counters
+// reported by PathCacheStats.
+func ClearPathCache() {
+	pathCache.Clear()
+	setPathCache.Clear()
+	pathCacheHits.Store(0)
+	pathCacheMisses.Store(0)
+}
+
 // GetPath represents a pre-compiled path for fast repeated GET operations.
 // Use CompileGetPath to create a GetPath, then call Run() to execute.
 // This avoids path parsing overhead when executing the same query multiple times.
@@ -269,6 +747,586 @@ func (p *GetPath) String() string {
 	return p.compiled.original
 }
 
+// Query is a path expression compiled once for repeated evaluation.
+// Unlike GetPath, which only precompiles simple dot paths, a Query keeps the
+// full token stream including filters and modifiers.
+type Query struct {
+	path   string
+	tokens []pathToken // nil when the path is served by Get's fast paths
+}
+
+// Compile parses path into a reusable Query.
+//
+// Example:
+//
+//	q, err := nqjson.Compile("items.#(price<10)#.name|@sort")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result := q.Eval(jsonData)
+func Compile(path string) (*Query, error) {
+	if path == "" {
+		return nil, ErrInvalidQuery
+	}
+
+	q := &Query{path: path}
+	if !needsTokenizedPath(path) {
+		return q, nil
+	}
+
+	q.tokens = tokenizePath(path)
+	if len(q.tokens) == 0 {
+		return nil, ErrInvalidQuery
+	}
+	return q, nil
+}
+
+// Path is the name CompilePath and GetWithCompiledPath use for a compiled
+// Query, pairing with SetPath on the write side.
+type Path = Query
+
+// CompilePath is Compile under the name that pairs with CompileSetPath.
+func CompilePath(path string) (*Path, error) {
+	return Compile(path)
+}
+
+// needsTokenizedPath reports whether Get would route path to the tokenized
+// path engine rather than a fast path, multipath or JSON Lines handling.
+func needsTokenizedPath(path string) bool {
+	if len(splitMultiPath(path)) > 1 || strings.HasPrefix(path, "..") {
+		return false
+	}
+	if path == "$" || path == "@" {
+		return false
+	}
+	return !isSimplePath(path) && !isUltraSimplePath(path)
+}
+
+// Eval runs the compiled query against data. It returns the same result as
+// Get(data, path) for the path it was compiled from.
+func (q *Query) Eval(data []byte) Result {
+	if q == nil {
+		return Result{Type: TypeUndefined}
+	}
+	if q.tokens == nil {
+		return Get(data, q.path)
+	}
+	if len(data) == 0 {
+		return Result{Type: TypeUndefined}
+	}
+	return rejectNonFinite(executeTokenizedPath(data, q.tokens))
+}
+
+// String returns the original path string.
+func (q *Query) String() string {
+	if q == nil {
+		return ""
+	}
+	return q.path
+}
+
+// GetWithCompiledPath gets a value using a path from CompilePath, the
+// read-side counterpart of SetWithCompiledPath. It is the same as p.Eval(json).
+func GetWithCompiledPath(json []byte, p *Path) Result {
+	return p.Eval(json)
+}
+
+// Doc is a parsed document for running many queries against the same
+// JSON. For an object root it keeps an index of the top-level members, so a
+// path's first key is found without rescanning the document.
+type Doc struct {
+	data []byte
+	keys map[string]docSpan
+}
+
+// docSpan is the byte range of a top-level member value. A start of -1
+// marks a key written with escapes, which is left to Get to match.
+type docSpan struct {
+	start, end int
+}
+
+// ParseDoc validates data and returns a Doc for repeated queries. The Doc
+// references data, which must not be modified while the Doc is in use.
+func ParseDoc(data []byte) (*Doc, error) {
+	d := &Doc{data: data}
+	start := skipJSONSpace(data, 0)
+	if start >= len(data) || data[start] != '{' {
+		if !Valid(data) {
+			return nil, ErrInvalidJSON
+		}
+		return d, nil
+	}
+
+	// Validate and index the top-level members in a single pass
+	d.keys = make(map[string]docSpan)
+	end, err := scanJSONMembers(data, start, func(key []byte, start, end int) {
+		// Keys alias data; the first occurrence wins, as with Get
+		k := bytesToString(key)
+		if bytes.IndexByte(key, '\\') >= 0 {
+			k, start = unescapeStringContent(key), -1
+		}
+		if _, dup := d.keys[k]; !dup {
+			d.keys[k] = docSpan{start: start, end: end}
+		}
+	})
+	if err != nil || skipJSONSpace(data, end) != len(data) {
+		return nil, ErrInvalidJSON
+	}
+	return d, nil
+}
+
+// Get runs path against the document. It returns the same result as
+// Get(data, path).
+func (d *Doc) Get(path string) Result {
+	if d == nil {
+		return Result{Type: TypeUndefined}
+	}
+
+	key, rest, ok := cutDocKey(path)
+	if !ok || d.keys == nil {
+		return Get(d.data, path)
+	}
+	span, found := d.keys[key]
+	if !found || span.start < 0 {
+		return Get(d.data, path)
+	}
+
+	value := d.data[span.start:span.end]
+	if rest == "" {
+		return rebaseResult(rejectNonFinite(parseAny(value)), span.start)
+	}
+	return rebaseResult(Get(value, rest), span.start)
+}
+
+// rebaseResult shifts the offsets of a result found within a member value so
+// they are relative to the whole document. A zero Index is left as is, since
+// Get uses it when the offset is not known.
+func rebaseResult(r Result, offset int) Result {
+	if r.Index > 0 {
+		r.Index += offset
+	}
+	if len(r.Indexes) > 0 {
+		indexes := make([]int, len(r.Indexes))
+		for i, index := range r.Indexes {
+			indexes[i] = index + offset
+		}
+		r.Indexes = indexes
+	}
+	return r
+}
+
+// Bytes returns the underlying JSON.
+func (d *Doc) Bytes() []byte {
+	if d == nil {
+		return nil
+	}
+	return d.data
+}
+
+// cutDocKey splits a plain leading key off path. ok is false when the first
+// segment uses any path syntax beyond a literal key, or when the path as a
+// whole is a multipath, a pipe chain or a recursive or JSON Lines query,
+// whose meaning depends on the entire document.
+func cutDocKey(path string) (key, rest string, ok bool) {
+	if strings.ContainsAny(path, ",|") || strings.Contains(path, "..") {
+		return "", "", false
+	}
+	key, rest, _ = strings.Cut(path, ".")
+	if key == "" {
+		return "", "", false
+	}
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '\\', ':', '|', '@', '*', '?', '#', ',', '(', ')', '=', '!', '<', '>', '~', '[', ']', '{', '}', '$':
+			return "", "", false
+		}
+	}
+	return key, rest, true
+}
+
+// ErrStopScan may be returned from a TokenHandler callback to end Scan early.
+// Scan then returns nil.
+var ErrStopScan = errors.New("stop scan")
+
+// TokenHandler receives the events of a Scan in document order. Returning a
+// non-nil error from any callback stops the scan; Scan returns that error
+// unless it is ErrStopScan.
+type TokenHandler interface {
+	OnObjectStart() error
+	OnObjectEnd() error
+	OnArrayStart() error
+	OnArrayEnd() error
+	// OnKey receives an object key without quotes. It aliases json unless
+	// the key contains escapes, and must not be retained after the call.
+	OnKey(key []byte) error
+	// OnValue receives every string, number, boolean and null value.
+	OnValue(v Result) error
+}
+
+// Scan walks json in a single forward pass, calling handler for each token.
+// Syntax is checked strictly as it goes; on malformed input Scan returns
+// ErrInvalidJSON, possibly after some callbacks have already run.
+func Scan(json []byte, handler TokenHandler) error {
+	end, err := scanTokens(json, 0, handler)
+	if err == nil && skipJSONSpace(json, end) != len(json) {
+		err = ErrInvalidJSON
+	}
+	if errors.Is(err, ErrStopScan) {
+		return nil
+	}
+	return err
+}
+
+// scanTokens scans the value at or after data[i], reporting it to h, and
+// returns the offset just past the value
+func scanTokens(data []byte, i int, h TokenHandler) (int, error) {
+	i = skipJSONSpace(data, i)
+	if i >= len(data) {
+		return i, ErrInvalidJSON
+	}
+
+	switch data[i] {
+	case '{':
+		return scanObjectTokens(data, i, h)
+	case '[':
+		return scanArrayTokens(data, i, h)
+	}
+
+	end, err := scanJSONValue(data, i)
+	if err != nil {
+		return end, ErrInvalidJSON
+	}
+	return end, h.OnValue(fastParseValue(data[i:end]))
+}
+
+func scanObjectTokens(data []byte, i int, h TokenHandler) (int, error) {
+	if err := h.OnObjectStart(); err != nil {
+		return i, err
+	}
+	i = skipJSONSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return i + 1, h.OnObjectEnd()
+	}
+
+	for {
+		if i >= len(data) || data[i] != '"' {
+			return i, ErrInvalidJSON
+		}
+		end, err := scanJSONString(data, i)
+		if err != nil {
+			return end, ErrInvalidJSON
+		}
+		key := data[i+1 : end-1]
+		if bytes.IndexByte(key, '\\') >= 0 {
+			key = []byte(unescapeStringContent(key))
+		}
+		if err = h.OnKey(key); err != nil {
+			return end, err
+		}
+		i = skipJSONSpace(data, end)
+		if i >= len(data) || data[i] != ':' {
+			return i, ErrInvalidJSON
+		}
+		if i, err = scanTokens(data, i+1, h); err != nil {
+			return i, err
+		}
+		i = skipJSONSpace(data, i)
+		if i >= len(data) {
+			return i, ErrInvalidJSON
+		}
+		switch data[i] {
+		case '}':
+			return i + 1, h.OnObjectEnd()
+		case ',':
+			i = skipJSONSpace(data, i+1)
+		default:
+			return i, ErrInvalidJSON
+		}
+	}
+}
+
+func scanArrayTokens(data []byte, i int, h TokenHandler) (int, error) {
+	if err := h.OnArrayStart(); err != nil {
+		return i, err
+	}
+	i = skipJSONSpace(data, i+1)
+	if i < len(data) && data[i] == ']' {
+		return i + 1, h.OnArrayEnd()
+	}
+
+	for {
+		var err error
+		if i, err = scanTokens(data, i, h); err != nil {
+			return i, err
+		}
+		i = skipJSONSpace(data, i)
+		if i >= len(data) {
+			return i, ErrInvalidJSON
+		}
+		switch data[i] {
+		case ']':
+			return i + 1, h.OnArrayEnd()
+		case ',':
+			i++
+		default:
+			return i, ErrInvalidJSON
+		}
+	}
+}
+
+// NodeCount returns the number of values in json, counting every object,
+// array and scalar including the root, so {"a":[1,2]} has 4 nodes. It makes
+// a single strict pass and returns ErrInvalidJSON for malformed input.
+func NodeCount(json []byte) (int, error) {
+	var c nodeCounter
+	if err := Scan(json, &c); err != nil {
+		return 0, err
+	}
+	return int(c), nil
+}
+
+// nodeCounter is the TokenHandler behind NodeCount
+type nodeCounter int
+
+func (c *nodeCounter) OnObjectStart() error { *c++; return nil }
+func (c *nodeCounter) OnObjectEnd() error   { return nil }
+func (c *nodeCounter) OnArrayStart() error  { *c++; return nil }
+func (c *nodeCounter) OnArrayEnd() error    { return nil }
+func (c *nodeCounter) OnKey([]byte) error   { return nil }
+func (c *nodeCounter) OnValue(Result) error { *c++; return nil }
+
+// GetOptions enables optional, non-standard parsing behavior for GetWithOptions.
+// The zero value behaves exactly like Get.
+type GetOptions struct {
+	// AllowNonFiniteNumbers accepts the NaN, Infinity and -Infinity tokens
+	// emitted by some producers as numbers. The scanners always step over
+	// them; without this option a path that resolves to one is missing.
+	AllowNonFiniteNumbers bool
+
+	// ParseIntStrings returns a string holding a 0x, 0o or 0b prefixed
+	// integer, such as "0x1F", as a number. The number's Raw is its decimal
+	// form, so "0x1F" reads as 31. Other strings are returned unchanged.
+	ParseIntStrings bool
+
+	// CollapseStringWhitespace replaces each run of whitespace in a string
+	// result's unescaped text with a single space. Raw is left unchanged.
+	CollapseStringWhitespace bool
+
+	// MergeDuplicateKeys deep-merges object values that appear under the
+	// same key more than once, so {"a":{"x":1},"a":{"y":2}} reads a as
+	// {"x":1,"y":2}. Duplicates are otherwise resolved as Get resolves them:
+	// the first occurrence wins. When the first occurrence is an object, the
+	// later objects are merged into it and later non-object values are
+	// ignored; when it is not an object, it is returned as-is. Conflicting
+	// members of merged objects follow the same rule. Duplicates are merged
+	// along the path as it is looked up and within the returned value, so
+	// the rest of the document is not rewritten.
+	MergeDuplicateKeys bool
+}
+
+// GetWithOptions retrieves a value like Get, applying the given options.
+// A nil options pointer is equivalent to calling Get.
+func GetWithOptions(data []byte, path string, options *GetOptions) Result {
+	if options == nil {
+		return Get(data, path)
+	}
+
+	result := getWithOptions(data, path, getOptions{allowMultipath: true, allowJSONLines: true, mergeDuplicates: options.MergeDuplicateKeys})
+	if options.MergeDuplicateKeys && (result.Type == TypeObject || result.Type == TypeArray) {
+		if merged, ok := mergeDuplicateKeys(result.Raw); ok {
+			result = fastParseValue(merged)
+		}
+	}
+	if !options.AllowNonFiniteNumbers {
+		result = rejectNonFinite(result)
+	}
+	if options.ParseIntStrings && result.Type == TypeString {
+		if n, ok := parsePrefixedInt(result.Str); ok {
+			n.Index, n.Path, n.Indexes = result.Index, result.Path, result.Indexes
+			result = n
+		}
+	}
+	if options.CollapseStringWhitespace && result.Type == TypeString {
+		result.Str = collapseWhitespace(result.Str)
+	}
+	return result
+}
+
+// collapseWhitespace replaces each run of Unicode whitespace in s with one space
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	inSpace := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !inSpace {
+				b.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// mergeDuplicateKeys rewrites the value in data with duplicate object keys
+// merged as described for GetOptions.MergeDuplicateKeys. It reports false if
+// data has no duplicate keys or is not valid JSON.
+func mergeDuplicateKeys(data []byte) ([]byte, bool) {
+	start := skipJSONSpace(data, 0)
+	end, err := scanJSONValue(data, start)
+	if err != nil {
+		return nil, false
+	}
+
+	merged := false
+	out := appendMergedValue(make([]byte, 0, end-start), data, docSpan{start, end}, &merged)
+	return out, merged
+}
+
+// findMergedMember looks up key in the object data like fastFindObjectValue,
+// merging duplicate occurrences as described for GetOptions.MergeDuplicateKeys
+func findMergedMember(data []byte, key string) Result {
+	var spans []docSpan
+	_, err := scanJSONMembers(data, skipJSONSpace(data, 0), func(k []byte, start, end int) {
+		if matchKeyBytes(k, 0, len(k), key) {
+			spans = append(spans, docSpan{start, end})
+		}
+	})
+	if err != nil {
+		spans = spans[:0]
+		if start, end := fastFindObjectValue(data, key); start != -1 {
+			spans = append(spans, docSpan{start, end})
+		}
+	}
+
+	spans = mergeableSpans(data, spans)
+	switch len(spans) {
+	case 0:
+		return Result{Type: TypeUndefined}
+	case 1:
+		return fastParseValue(data[spans[0].start:spans[0].end])
+	}
+	merged := false
+	return fastParseValue(appendMergedObjects(nil, data, spans, &merged))
+}
+
+// mergeableSpans returns the duplicate values in spans that make up the
+// merged value: every object when the first value is an object, otherwise
+// just the first value
+func mergeableSpans(data []byte, spans []docSpan) []docSpan {
+	if len(spans) < 2 || data[spans[0].start] != '{' {
+		return spans[:min(len(spans), 1)]
+	}
+	objects := spans[:1:1]
+	for _, span := range spans[1:] {
+		if data[span.start] == '{' {
+			objects = append(objects, span)
+		}
+	}
+	return objects
+}
+
+// appendMergedValue appends the value at span with duplicate keys merged
+func appendMergedValue(dst, data []byte, span docSpan, merged *bool) []byte {
+	switch data[span.start] {
+	case '{':
+		return appendMergedObjects(dst, data, []docSpan{span}, merged)
+	case '[':
+		dst = append(dst, '[')
+		n := 0
+		scanJSONElements(data, span.start, func(start, end int) {
+			if n > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendMergedValue(dst, data, docSpan{start, end}, merged)
+			n++
+		})
+		return append(dst, ']')
+	default:
+		return append(dst, data[span.start:span.end]...)
+	}
+}
+
+// appendMergedObjects appends one object holding the members of all objects
+// in spans, keeping each key at its first position
+func appendMergedObjects(dst, data []byte, spans []docSpan, merged *bool) []byte {
+	var keys [][]byte
+	values := make(map[string][]docSpan)
+	for _, span := range spans {
+		scanJSONMembers(data, span.start, func(key []byte, start, end int) {
+			k := bytesToString(key)
+			if _, seen := values[k]; !seen {
+				keys = append(keys, key)
+			}
+			values[k] = append(values[k], docSpan{start, end})
+		})
+	}
+
+	dst = append(dst, '{')
+	for i, key := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, '"')
+		dst = append(dst, key...)
+		dst = append(dst, '"', ':')
+
+		spans := values[bytesToString(key)]
+		if len(spans) > 1 {
+			*merged = true
+		}
+		if spans = mergeableSpans(data, spans); len(spans) == 1 {
+			dst = appendMergedValue(dst, data, spans[0], merged)
+		} else {
+			dst = appendMergedObjects(dst, data, spans, merged)
+		}
+	}
+	return append(dst, '}')
+}
+
+// parsePrefixedInt parses an optionally signed integer with a 0x, 0o or 0b
+// prefix into a number result
+func parsePrefixedInt(s string) (Result, bool) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 || len(digits) < 3 || digits[0] != '0' {
+		return Result{}, false
+	}
+	switch digits[1] {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+	default:
+		return Result{}, false
+	}
+
+	var raw []byte
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		raw = strconv.AppendInt(nil, n, 10)
+	} else if n, err := strconv.ParseUint(digits, 0, 64); err == nil && s[0] != '-' {
+		raw = strconv.AppendUint(nil, n, 10)
+	} else {
+		return Result{}, false
+	}
+	num, _ := strconv.ParseFloat(string(raw), 64)
+	return Result{Type: TypeNumber, Num: num, Raw: raw, Modified: true}, true
+}
+
+// rawOffset reports the offset of raw within buf if raw is a sub-slice of buf.
+func rawOffset(buf, raw []byte) (int, bool) {
+	if len(raw) == 0 || len(buf) == 0 {
+		return 0, false
+	}
+	//nolint:gosec //G103
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(buf)))
+	//nolint:gosec //G103
+	ptr := uintptr(unsafe.Pointer(unsafe.SliceData(raw)))
+	if ptr < base || ptr+uintptr(len(raw)) > base+uintptr(len(buf)) {
+		return 0, false
+	}
+	return int(ptr - base), true
+}
+
 // unescapePathGet unescapes special characters in a path segment for GET operations
 // Supports: \\ . : | @ * ? # , ( ) = ! < > ~
 func unescapePathGet(s string) string {
@@ -499,7 +1557,7 @@ func shouldHandleMultipath(path string, opts getOptions) bool {
 func getSinglePathResult(data []byte, path string, opts getOptions) Result {
 	// JSON Lines support: treat leading ".." prefix as newline-delimited documents when applicable.
 	if opts.allowJSONLines && len(path) >= 2 && path[0] == '.' && path[1] == '.' {
-		if jsonLinesResult, handled := getJSONLinesResult(data, path); handled {
+		if jsonLinesResult, handled := getJSONLinesResult(data, path, opts); handled {
 			return jsonLinesResult
 		}
 	}
@@ -514,6 +1572,17 @@ func getSinglePathResult(data []byte, path string, opts getOptions) Result {
 		return Result{Type: TypeUndefined}
 	}
 
+	// Collections under GetWithLimit; bracket wildcards such as a[*] would
+	// otherwise take the simple path below
+	if opts.limit > 0 && (strings.ContainsAny(path, "*?#") || strings.Contains(path, "..")) {
+		return getLimitedPath(data, path, opts.limit)
+	}
+
+	// The fast paths below stop at the first occurrence of a key
+	if opts.mergeDuplicates {
+		return getMergedPath(data, path)
+	}
+
 	// Check if we can use the ultra-fast path for simple keys
 	if len(data) < 1024 && isUltraSimplePath(path) {
 		result := getUltraSimplePath(data, path)
@@ -543,7 +1612,7 @@ func getMultiPathResult(data []byte, path string, opts getOptions) (Result, bool
 		if segment == "" {
 			continue
 		}
-		subResult := getWithOptions(data, segment, getOptions{allowMultipath: false, allowJSONLines: opts.allowJSONLines})
+		subResult := getWithOptions(data, segment, getOptions{allowMultipath: false, allowJSONLines: opts.allowJSONLines, limit: opts.limit, mergeDuplicates: opts.mergeDuplicates})
 		if !subResult.Exists() {
 			subResult = buildNullResult()
 		}
@@ -688,7 +1757,7 @@ func buildNullResult() Result {
 	return Result{Type: TypeNull, Raw: []byte("null"), Modified: true}
 }
 
-func getJSONLinesResult(data []byte, path string) (Result, bool) {
+func getJSONLinesResult(data []byte, path string, opts getOptions) (Result, bool) {
 	values, ok := extractJSONLinesValues(data)
 	if !ok {
 		return Result{}, false
@@ -710,7 +1779,7 @@ func getJSONLinesResult(data []byte, path string) (Result, bool) {
 		return Parse(arrayBytes), true
 	}
 
-	return getWithOptions(arrayBytes, trimmedPath, getOptions{allowMultipath: true, allowJSONLines: false}), true
+	return getWithOptions(arrayBytes, trimmedPath, getOptions{allowMultipath: true, allowJSONLines: false, limit: opts.limit, mergeDuplicates: opts.mergeDuplicates}), true
 }
 
 // extractJSONLinesValues returns valid JSON documents when the input represents JSON Lines.
@@ -758,41 +1827,293 @@ func splitAndValidateJSONLines(data []byte, sep []byte) ([][]byte, bool) {
 		if !json.Valid(entry) {
 			return nil, false
 		}
-		values = append(values, entry)
+		values = append(values, entry)
+	}
+
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	return values, true
+}
+
+// buildJSONArrayFromLines constructs a JSON array from individual JSON documents.
+func buildJSONArrayFromLines(values [][]byte) []byte {
+	totalSize := 2 // opening and closing brackets
+	for i, v := range values {
+		if i > 0 {
+			totalSize++ // comma
+		}
+		totalSize += len(v)
+	}
+
+	result := make([]byte, 0, totalSize)
+	result = append(result, '[')
+	for i, v := range values {
+		if i > 0 {
+			result = append(result, ',')
+		}
+		result = append(result, v...)
+	}
+	result = append(result, ']')
+	return result
+}
+
+// GetLines parses the JSON Lines records from index from up to, but not
+// including, to. Blank lines are skipped and not counted, so indices match
+// the ..N path syntax. A malformed record yields an undefined Result in its
+// place. The results reference json.
+func GetLines(json []byte, from, to int) []Result {
+	if from < 0 {
+		from = 0
+	}
+	if to <= from {
+		return nil
+	}
+
+	results := make([]Result, 0, min(to-from, 64))
+	record := 0
+	for len(json) > 0 && record < to {
+		line := json
+		if i := bytes.IndexByte(json, '\n'); i >= 0 {
+			line, json = json[:i], json[i+1:]
+		} else {
+			json = nil
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if record >= from {
+			results = append(results, Parse(line))
+		}
+		record++
+	}
+	return results
+}
+
+// GetString is like Get but accepts a string input
+func GetString(json string, path string) Result {
+	return Get(stringToBytes(json), path)
+}
+
+// GetWithSeparator is like Get but splits path on sep instead of '.', so
+// dots inside keys need no escaping. A separator inside a key is escaped
+// with a backslash. Example: GetWithSeparator(json, "hosts/api.example.com/port", '/')
+func GetWithSeparator(data []byte, path string, sep rune) Result {
+	if sep == '.' {
+		return Get(data, path)
+	}
+	return Get(data, convertPathSeparator(path, sep))
+}
+
+// convertPathSeparator rewrites a sep-separated path into dot syntax
+func convertPathSeparator(path string, sep rune) string {
+	var b strings.Builder
+	b.Grow(len(path) + 4)
+	escaped := false
+	for _, r := range path {
+		switch {
+		case escaped:
+			escaped = false
+			if r == sep && (r >= utf8.RuneSelf || !shouldEscapePathChar(byte(r))) {
+				b.WriteRune(r)
+				continue
+			}
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+		case r == sep:
+			b.WriteByte('.')
+		case r == '.':
+			b.WriteString(`\.`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if escaped {
+		b.WriteByte('\\')
 	}
+	return b.String()
+}
 
-	if len(values) == 0 {
-		return nil, false
+// DefaultMaxDecompressedSize caps the bytes GetAuto will decompress,
+// guarding against small inputs that expand to huge documents.
+const DefaultMaxDecompressedSize int64 = 64 << 20
+
+// GetAuto is like Get but first decompresses data if it starts with a gzip
+// or zlib header. Any other input is queried as plain JSON. The error is
+// non-nil only when compressed input cannot be decompressed, or is
+// ErrTooLarge when it decompresses to more than DefaultMaxDecompressedSize
+// bytes.
+func GetAuto(data []byte, path string) (Result, error) {
+	return GetAutoWithMaxSize(data, path, DefaultMaxDecompressedSize)
+}
+
+// GetAutoWithMaxSize is GetAuto with its own cap on the decompressed size.
+// A maxSize <= 0 means no limit.
+func GetAutoWithMaxSize(data []byte, path string, maxSize int64) (Result, error) {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case isZlibHeader(data):
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return Get(data, path), nil
+	}
+	if err != nil {
+		return Result{Type: TypeUndefined}, err
 	}
+	defer r.Close()
 
-	return values, true
+	var src io.Reader = r
+	if maxSize > 0 {
+		// Read one byte past the limit to tell a full read from a cut one
+		src = io.LimitReader(r, maxSize+1)
+	}
+	plain, err := io.ReadAll(src)
+	if err != nil {
+		return Result{Type: TypeUndefined}, err
+	}
+	if maxSize > 0 && int64(len(plain)) > maxSize {
+		return Result{Type: TypeUndefined}, ErrTooLarge
+	}
+	return Get(plain, path), nil
 }
 
-// buildJSONArrayFromLines constructs a JSON array from individual JSON documents.
-func buildJSONArrayFromLines(values [][]byte) []byte {
-	totalSize := 2 // opening and closing brackets
-	for i, v := range values {
-		if i > 0 {
-			totalSize++ // comma
-		}
-		totalSize += len(v)
+// isZlibHeader reports whether data starts with a deflate zlib header. The
+// first byte is 'x', which never starts a JSON document.
+func isZlibHeader(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x78 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
+}
+
+// Encoding identifies the Unicode encoding of a JSON document
+type Encoding uint8
+
+const (
+	EncodingUTF8 Encoding = iota
+	EncodingUTF16LE
+	EncodingUTF16BE
+	EncodingUTF32LE
+	EncodingUTF32BE
+)
+
+// String returns the conventional name of the encoding, e.g. "UTF-16LE"
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingUTF32LE:
+		return "UTF-32LE"
+	case EncodingUTF32BE:
+		return "UTF-32BE"
+	}
+	return "UTF-8"
+}
+
+// DetectEncoding reports the encoding of data from its byte order mark or,
+// without one, from the pattern of zero bytes in the first four bytes (a
+// JSON document starts with an ASCII character). Anything else is UTF-8.
+func DetectEncoding(data []byte) Encoding {
+	enc, _ := detectEncoding(data)
+	return enc
+}
+
+// detectEncoding returns the encoding and the length of its byte order mark
+func detectEncoding(data []byte) (Encoding, int) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8, 3
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE, 0, 0}):
+		return EncodingUTF32LE, 4
+	case bytes.HasPrefix(data, []byte{0, 0, 0xFE, 0xFF}):
+		return EncodingUTF32BE, 4
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE, 2
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE, 2
+	}
+	if len(data) >= 4 {
+		switch {
+		case data[0] == 0 && data[1] == 0 && data[2] == 0 && data[3] != 0:
+			return EncodingUTF32BE, 0
+		case data[0] != 0 && data[1] == 0 && data[2] == 0 && data[3] == 0:
+			return EncodingUTF32LE, 0
+		}
+	}
+	if len(data) >= 2 {
+		switch {
+		case data[0] == 0 && data[1] != 0:
+			return EncodingUTF16BE, 0
+		case data[0] != 0 && data[1] == 0:
+			return EncodingUTF16LE, 0
+		}
+	}
+	return EncodingUTF8, 0
+}
+
+// ToUTF8 converts a UTF-16 or UTF-32 document to UTF-8 and drops any byte
+// order mark, so the result can be passed to Get. UTF-8 input without a
+// BOM is returned unchanged. Truncated input or an invalid code point
+// returns ErrInvalidJSON.
+func ToUTF8(data []byte) ([]byte, error) {
+	enc, bom := detectEncoding(data)
+	data = data[bom:]
+
+	var unit int
+	switch enc {
+	case EncodingUTF8:
+		return data, nil
+	case EncodingUTF16LE, EncodingUTF16BE:
+		unit = 2
+	default:
+		unit = 4
+	}
+	if len(data)%unit != 0 {
+		return nil, ErrInvalidJSON
 	}
 
-	result := make([]byte, 0, totalSize)
-	result = append(result, '[')
-	for i, v := range values {
-		if i > 0 {
-			result = append(result, ',')
+	out := make([]byte, 0, len(data)/unit)
+	for i := 0; i < len(data); i += unit {
+		var r rune
+		switch enc {
+		case EncodingUTF16LE, EncodingUTF16BE:
+			r = rune(readUnit16(data[i:], enc == EncodingUTF16BE))
+			if utf16.IsSurrogate(r) {
+				if i+4 > len(data) {
+					return nil, ErrInvalidJSON
+				}
+				r = utf16.DecodeRune(r, rune(readUnit16(data[i+2:], enc == EncodingUTF16BE)))
+				if r == utf8.RuneError {
+					return nil, ErrInvalidJSON // unpaired surrogate
+				}
+				i += 2
+			}
+		case EncodingUTF32LE:
+			r = rune(uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24)
+		case EncodingUTF32BE:
+			r = rune(uint32(data[i])<<24 | uint32(data[i+1])<<16 | uint32(data[i+2])<<8 | uint32(data[i+3]))
 		}
-		result = append(result, v...)
+		if !utf8.ValidRune(r) {
+			return nil, ErrInvalidJSON
+		}
+		out = utf8.AppendRune(out, r)
 	}
-	result = append(result, ']')
-	return result
+	return out, nil
 }
 
-// GetString is like Get but accepts a string input
-func GetString(json string, path string) Result {
-	return Get(stringToBytes(json), path)
+// readUnit16 reads one UTF-16 code unit
+func readUnit16(b []byte, bigEndian bool) uint16 {
+	if bigEndian {
+		return uint16(b[0])<<8 | uint16(b[1])
+	}
+	return uint16(b[0]) | uint16(b[1])<<8
 }
 
 // Parse parses a JSON value and returns a Result
@@ -940,7 +2261,7 @@ func Parse(data []byte) Result {
 			return result
 		}
 	default:
-		if isNumericChar(data[start]) {
+		if isNumericChar(data[start]) || nonFiniteLen(data, start) > 0 {
 			return parseNumber(data, start)
 		}
 	}
@@ -948,6 +2269,45 @@ func Parse(data []byte) Result {
 	return Result{Type: TypeUndefined}
 }
 
+// TryParse is like Parse but first validates data strictly. The boolean is
+// false, with an undefined Result, if data is not valid JSON.
+func TryParse(data []byte) (Result, bool) {
+	if !Valid(data) {
+		return Result{Type: TypeUndefined}, false
+	}
+	return Parse(data), true
+}
+
+// MustParse is like TryParse but panics if data is not valid JSON. It is
+// meant for trusted input such as embedded fixtures.
+func MustParse(data []byte) Result {
+	result, ok := TryParse(data)
+	if !ok {
+		panic(ErrInvalidJSON)
+	}
+	return result
+}
+
+// ParsePrefix parses the first complete JSON value in data and returns it
+// along with the number of bytes consumed. Anything after the value is left
+// untouched, which allows reading streams of concatenated values:
+//
+//	r, n, err := nqjson.ParsePrefix(buf) // buf = {"a":1}{"b":2}
+//	next, _, _ := nqjson.ParsePrefix(buf[n:])
+//
+// A *FormatError describing the problem is returned if no valid value is found.
+func ParsePrefix(data []byte) (Result, int, error) {
+	end, err := scanJSONValue(data, 0)
+	if err != nil {
+		return Result{Type: TypeUndefined}, 0, err
+	}
+
+	start := skipLeadingWhitespace(data)
+	result := parseAny(data[start:end])
+	result.Index = start
+	return result, end, nil
+}
+
 // GetMany executes multiple queries against the same JSON data
 func GetMany(data []byte, paths ...string) []Result {
 	if len(paths) == 0 {
@@ -962,6 +2322,342 @@ func GetMany(data []byte, paths ...string) []Result {
 	return results
 }
 
+// Rule is a presence and type check on one path, used by Assert.
+// A Type of TypeUndefined accepts any type.
+type Rule struct {
+	Path     string
+	Required bool
+	Type     ValueType
+}
+
+// RuleError reports a Rule violated by a document.
+type RuleError struct {
+	Path    string
+	Missing bool      // a required value is absent
+	Want    ValueType // expected type when Missing is false
+	Got     ValueType
+}
+
+func (e *RuleError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("path %q: required value is missing", e.Path)
+	}
+	return fmt.Sprintf("path %q: expected %s, got %s", e.Path, typeName(e.Want), typeName(e.Got))
+}
+
+// Assert checks every rule against json and returns all violations as
+// *RuleError values, in rule order. An optional path that is absent passes.
+// Invalid JSON is reported as ErrInvalidJSON.
+func Assert(json []byte, rules []Rule) []error {
+	if !Valid(json) {
+		return []error{ErrInvalidJSON}
+	}
+
+	var errs []error
+	for _, rule := range rules {
+		value := Get(json, rule.Path)
+		switch {
+		case !value.Exists():
+			if rule.Required {
+				errs = append(errs, &RuleError{Path: rule.Path, Missing: true})
+			}
+		case rule.Type != TypeUndefined && value.Type != rule.Type:
+			errs = append(errs, &RuleError{Path: rule.Path, Want: rule.Type, Got: value.Type})
+		}
+	}
+	return errs
+}
+
+// ArrayLen returns the number of elements of the array at path without
+// materializing them. An empty path refers to the document root. The boolean
+// is false if the value does not exist or is not an array.
+func ArrayLen(data []byte, path string) (int, bool) {
+	var result Result
+	if path == "" {
+		result = Parse(data)
+	} else {
+		result = Get(data, path)
+	}
+	if result.Type != TypeArray {
+		return 0, false
+	}
+
+	raw := bytes.TrimSpace(result.Raw)
+	if len(raw) < 2 {
+		return 0, false
+	}
+	return calculateArrayLength(raw[1 : len(raw)-1]), true
+}
+
+// LocateMember returns the byte span of the object member at path, from the
+// opening quote of its key to the end of its value, so that json[keyStart:valueEnd]
+// is `"key": value`. To cut the member out, also drop the comma that follows
+// valueEnd, or for the last member the comma before keyStart. ok is false if
+// path does not name a member of an object.
+func LocateMember(json []byte, path string) (keyStart, valueEnd int, ok bool) {
+	segs := splitPathGet(path)
+	last := segs[len(segs)-1]
+	if last == "" || hasUnescapedWildcard(last) {
+		return 0, 0, false
+	}
+
+	parent, offset := json, 0
+	if len(segs) == 1 && !isRootObject(json) {
+		return 0, 0, false
+	}
+	if len(segs) > 1 {
+		p := Get(json, strings.Join(segs[:len(segs)-1], "."))
+		if p.Type != TypeObject || p.Modified {
+			return 0, 0, false
+		}
+		if offset, ok = rawOffset(json, p.Raw); !ok {
+			return 0, 0, false
+		}
+		parent = p.Raw
+	}
+
+	key := unescapePathGet(last)
+	if hasColonPrefixGet(last) {
+		key = stripColonPrefixGet(key)
+	}
+	start, _, end := findKeyValueRange(parent, key)
+	if start < 0 {
+		return 0, 0, false
+	}
+	return offset + start, offset + end, true
+}
+
+// WalkFunc is called by Walk for every value in a document. The path is
+// usable with Get; the root is reported with an empty path. Returning false
+// stops the walk.
+type WalkFunc func(path string, value Result) bool
+
+// Walk visits every value in data depth-first, parents before children.
+func Walk(data []byte, fn WalkFunc) {
+	root := Parse(data)
+	if !root.Exists() {
+		return
+	}
+	walkValue("", root, fn)
+}
+
+func walkValue(path string, value Result, fn WalkFunc) bool {
+	if !fn(path, value) {
+		return false
+	}
+	if value.Type != TypeObject && value.Type != TypeArray {
+		return true
+	}
+
+	cont := true
+	value.ForEach(func(key, child Result) bool {
+		cont = walkValue(joinWalkPath(path, key, value.Type == TypeArray), child, fn)
+		return cont
+	})
+	return cont
+}
+
+// joinWalkPath appends an array index or escaped object key to path
+func joinWalkPath(path string, key Result, isArray bool) string {
+	var seg string
+	switch {
+	case isArray:
+		seg = key.Str
+	case isAllDigitsGet(key.Str):
+		// Force object-key semantics for numeric-looking keys
+		seg = ":" + key.Str
+	default:
+		seg = EscapePathSegment(key.Str)
+	}
+	if path == "" {
+		return seg
+	}
+	return path + "." + seg
+}
+
+// AllOfType returns every value of type t in data, in document order.
+// Example: AllOfType(json, TypeNumber) collects all numbers at any depth.
+func AllOfType(data []byte, t ValueType) []Result {
+	var results []Result
+	Walk(data, func(_ string, value Result) bool {
+		if value.Type == t {
+			results = append(results, value)
+		}
+		return true
+	})
+	return results
+}
+
+// KeysAtDepth returns the distinct object keys found at depth, in order of
+// first appearance. Keys of the root object are at depth 0; each object or
+// array level below adds one, so in {"a":[{"b":1}]} "b" is at depth 2.
+func KeysAtDepth(data []byte, depth int) []string {
+	var keys []string
+	seen := make(map[string]struct{})
+	Walk(data, func(path string, value Result) bool {
+		if value.Type != TypeObject {
+			return true
+		}
+		level := 0
+		if path != "" {
+			level = len(splitPathGet(path))
+		}
+		if level != depth {
+			return true
+		}
+		value.ForEach(func(key, _ Result) bool {
+			if _, dup := seen[key.Str]; !dup {
+				seen[key.Str] = struct{}{}
+				keys = append(keys, key.Str)
+			}
+			return true
+		})
+		return true
+	})
+	return keys
+}
+
+// KeyedResult is a value paired with the path it was found at
+type KeyedResult struct {
+	Path  string
+	Value Result
+}
+
+// CollectWithPaths returns every value stored under key at any depth, in
+// document order, together with its path. Matches nested inside a matched
+// value are reported as well.
+// Example: CollectWithPaths(json, "id")
+func CollectWithPaths(data []byte, key string) []KeyedResult {
+	var results []KeyedResult
+	root := Parse(data)
+	if root.Exists() {
+		collectKeyed("", root, key, &results)
+	}
+	return results
+}
+
+func collectKeyed(path string, value Result, key string, results *[]KeyedResult) {
+	if value.Type != TypeObject && value.Type != TypeArray {
+		return
+	}
+	isArray := value.Type == TypeArray
+	value.ForEach(func(k, child Result) bool {
+		childPath := joinWalkPath(path, k, isArray)
+		if !isArray && k.String() == key {
+			*results = append(*results, KeyedResult{Path: childPath, Value: child})
+		}
+		collectKeyed(childPath, child, key, results)
+		return true
+	})
+}
+
+// GetRegex returns the members of the root object whose key matches the
+// regular expression keyPattern, in document order. A pattern that does not
+// compile is reported as ErrInvalidQuery.
+// Example: GetRegex(json, `^user_\d+$`)
+func GetRegex(json []byte, keyPattern string) ([]KeyedResult, error) {
+	return GetRegexDepth(json, keyPattern, 0)
+}
+
+// GetRegexDepth is like GetRegex but also searches objects nested up to
+// maxDepth levels below the root, counting arrays as a level. A negative
+// maxDepth searches the whole document.
+func GetRegexDepth(json []byte, keyPattern string, maxDepth int) ([]KeyedResult, error) {
+	re, err := regexp.Compile(keyPattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	var results []KeyedResult
+	root := Parse(json)
+	if root.Exists() {
+		collectRegex("", root, re, maxDepth, &results)
+	}
+	return results, nil
+}
+
+func collectRegex(path string, value Result, re *regexp.Regexp, depth int, results *[]KeyedResult) {
+	if value.Type != TypeObject && value.Type != TypeArray {
+		return
+	}
+	isArray := value.Type == TypeArray
+	value.ForEach(func(k, child Result) bool {
+		childPath := joinWalkPath(path, k, isArray)
+		if !isArray && re.MatchString(k.Str) {
+			*results = append(*results, KeyedResult{Path: childPath, Value: child})
+		}
+		if depth != 0 {
+			collectRegex(childPath, child, re, depth-1, results)
+		}
+		return true
+	})
+}
+
+// Match returns every value whose path matches glob, keyed by path. In the
+// glob, "*" matches exactly one segment and "**" matches any number of
+// segments; other segments may contain * and ? wildcards or escaped literals.
+//
+// Example: Match(json, "users.*.email") or Match(json, "**.id")
+func Match(data []byte, glob string) map[string]Result {
+	matches := make(map[string]Result)
+	if glob == "" {
+		return matches
+	}
+	pattern := splitPathGet(glob)
+
+	Walk(data, func(path string, value Result) bool {
+		if path != "" && matchGlobSegments(splitPathGet(path), pattern) {
+			matches[path] = value
+		}
+		return true
+	})
+	return matches
+}
+
+// matchGlobSegments matches escaped path segments against glob segments
+func matchGlobSegments(segs, pattern []string) bool {
+	if len(pattern) == 0 {
+		return len(segs) == 0
+	}
+
+	switch pattern[0] {
+	case "**":
+		for i := 0; i <= len(segs); i++ {
+			if matchGlobSegments(segs[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(segs) > 0 && matchGlobSegments(segs[1:], pattern[1:])
+	}
+
+	if len(segs) == 0 || !matchGlobSegment(segs[0], pattern[0]) {
+		return false
+	}
+	return matchGlobSegments(segs[1:], pattern[1:])
+}
+
+func matchGlobSegment(seg, pattern string) bool {
+	key := stripColonPrefixGet(unescapePathGet(seg))
+	if hasUnescapedWildcard(pattern) {
+		return matchKeyPattern(key, pattern)
+	}
+	return key == stripColonPrefixGet(unescapePathGet(pattern))
+}
+
+// hasUnescapedWildcard reports whether s contains a * or ? not preceded by a backslash
+func hasUnescapedWildcard(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '*', '?':
+			return true
+		}
+	}
+	return false
+}
+
 // getUltraSimplePath is an ultra-fast path for very simple JSON with basic paths
 // This handles cases like {"name":"John","age":30} with path "name"
 //
@@ -1328,16 +3024,15 @@ func scanKeyInObject(data []byte, pos int) (keyStart, keyEnd, newPos int) {
 
 // matchKeyBytes checks if key in data matches segment
 func matchKeyBytes(data []byte, keyStart, keyEnd int, segment string) bool {
-	segLen := len(segment)
-	if keyEnd-keyStart != segLen {
-		return false
+	key := data[keyStart:keyEnd]
+	if len(key) == len(segment) {
+		return string(key) == segment
 	}
-	for i := 0; i < segLen; i++ {
-		if data[keyStart+i] != segment[i] {
-			return false
-		}
+	// A key with JSON escapes is longer than its text; compare it unescaped
+	if len(key) < len(segment) || bytes.IndexByte(key, '\\') < 0 {
+		return false
 	}
-	return true
+	return unescapeStringContent(key) == segment
 }
 
 // skipToObjectValue skips whitespace and colon to get to value position
@@ -1437,6 +3132,13 @@ func parseArrayRecursive(data []byte, pos int, path string) Result {
 	if !ok {
 		return Result{Type: TypeUndefined}
 	}
+	if idx < 0 {
+		// Negative indices count back from the end: -1 is the last element
+		idx += countArrayElements(data, pos)
+		if idx < 0 {
+			return Result{Type: TypeUndefined}
+		}
+	}
 
 	// Skip to target index
 	pos = skipToArrayIndex(data, pos, idx)
@@ -1470,18 +3172,26 @@ func parseArrayRecursive(data []byte, pos int, path string) Result {
 
 // parseArrayIndexFromPath extracts the numeric index from the start of the path
 func parseArrayIndexFromPath(path string) (int, string, bool) {
+	// A leading minus selects from the end of the array
+	i := 0
+	if len(path) > 1 && path[0] == '-' {
+		i = 1
+	}
+
 	// Check if path starts with array index
-	if len(path) == 0 || (path[0] < '0' || path[0] > '9') {
+	if i >= len(path) || (path[i] < '0' || path[i] > '9') {
 		return 0, "", false
 	}
 
 	// Parse index
 	idx := 0
-	i := 0
 	for i < len(path) && path[i] >= '0' && path[i] <= '9' {
 		idx = idx*10 + int(path[i]-'0')
 		i++
 	}
+	if path[0] == '-' {
+		idx = -idx
+	}
 
 	remainingPath := ""
 	if i < len(path) {
@@ -1495,6 +3205,26 @@ func parseArrayIndexFromPath(path string) (int, string, bool) {
 	return idx, remainingPath, true
 }
 
+// countArrayElements counts the elements of the array whose content starts
+// at pos, just after the opening bracket
+func countArrayElements(data []byte, pos int) int {
+	n := 0
+	for {
+		pos = skipWhitespaceInline(data, pos)
+		if pos >= len(data) || data[pos] == ']' {
+			return n
+		}
+		if pos = vectorizedSkipValue(data, pos, len(data)); pos == -1 {
+			return n
+		}
+		n++
+		pos = skipWhitespaceInline(data, pos)
+		if pos < len(data) && data[pos] == ',' {
+			pos++
+		}
+	}
+}
+
 // skipToArrayIndex skips array elements until reaching targetIdx
 func skipToArrayIndex(data []byte, pos, targetIdx int) int {
 	currentIdx := 0
@@ -1974,19 +3704,15 @@ func skipToNextKeyInFastFind(data []byte, pos int) int {
 
 // checkKeyMatchInFastFind checks if the current key matches our target and returns value bounds if so
 func checkKeyMatchInFastFind(data []byte, pos int, key string, keyLen int) (int, int) {
-	// Check if this key matches
-	if pos+keyLen+1 < len(data) && data[pos+keyLen+1] == '"' {
-		match := true
-		for i := 0; i < keyLen; i++ {
-			if data[pos+1+i] != key[i] {
-				match = false
-				break
-			}
-		}
+	// Fast check: same length and a closing quote right after
+	if pos+keyLen+1 < len(data) && data[pos+keyLen+1] == '"' && string(data[pos+1:pos+1+keyLen]) == key {
+		return extractValueBoundsInFastFind(data, pos+keyLen+2)
+	}
 
-		if match {
-			return extractValueBoundsInFastFind(data, pos+keyLen+2)
-		}
+	// Longer keys can still match once their JSON escapes are decoded
+	end := fastSkipQuotedStringGet(data, pos)
+	if end != -1 && end-pos-2 > keyLen && matchKeyBytes(data, pos+1, end-1, key) {
+		return extractValueBoundsInFastFind(data, end)
 	}
 	return -1, -1
 }
@@ -2422,6 +4148,9 @@ func matchLiteralAt(data []byte, pos int, lit string) int {
 
 // fastSkipNumber efficiently skips over a JSON number
 func fastSkipNumber(data []byte, start int) int {
+	if n := nonFiniteLen(data, start); n > 0 {
+		return start + n
+	}
 	pos := start
 	for pos < len(data) {
 		c := data[pos]
@@ -2459,8 +4188,10 @@ func fastSkipValue(data []byte, start int) int {
 // parseStringValueFast parses a string value for fastParseValue
 func parseStringValueFast(data []byte, start int) Result {
 	end := start + 1
+	escaped := false
 	for ; end < len(data); end++ {
 		if data[end] == '\\' {
+			escaped = true
 			end++ // Skip escape character
 			continue
 		}
@@ -2476,6 +4207,15 @@ func parseStringValueFast(data []byte, start int) Result {
 	raw := data[start : end+1]
 	str := raw[1 : len(raw)-1] // Remove quotes
 
+	// Raw keeps the exact source token; Str is the unescaped content
+	if escaped {
+		return Result{
+			Type:  TypeString,
+			Str:   unescapeStringContent(str),
+			Raw:   raw,
+			Index: start,
+		}
+	}
 	return Result{
 		Type:  TypeString,
 		Str:   bytesToString(str),
@@ -2520,7 +4260,7 @@ func fastParseValue(data []byte) Result {
 			Index: start,
 		}
 	default:
-		if isNumericChar(data[start]) {
+		if isNumericChar(data[start]) || nonFiniteLen(data, start) > 0 {
 			return parseNumber(data, start)
 		}
 	}
@@ -2535,15 +4275,51 @@ func getComplexPath(data []byte, path string) Result {
 	cachedTokens, found := pathCache.Load(path)
 	var tokens []pathToken
 	if found {
-		tokens = cachedTokens.([]pathToken)
-	} else {
+		tokens, found = cachedTokens.([]pathToken)
+	}
+	if !found {
 		tokens = tokenizePath(path)
 		if len(tokens) == 0 {
 			return Result{Type: TypeUndefined}
 		}
-		pathCache.Store(path, tokens)
+		pathCache.Store(path, tokens)
+	}
+
+	return executeTokenizedPath(data, tokens)
+}
+
+// getMergedPath is getComplexPath for GetOptions.MergeDuplicateKeys. Like
+// getLimitedPath, it works on a copy of the tokens.
+func getMergedPath(data []byte, path string) Result {
+	tokens := tokenizePath(path)
+	if len(tokens) == 0 {
+		return Result{Type: TypeUndefined}
+	}
+	for i := range tokens {
+		if tokens[i].kind == tokenKey && !tokens[i].glob {
+			tokens[i].merge = true
+		}
 	}
+	return executeTokenizedPath(data, tokens)
+}
 
+// getLimitedPath is getComplexPath for GetWithLimit. The tokens are copied
+// so the limit doesn't leak into the shared path cache.
+func getLimitedPath(data []byte, path string, limit int) Result {
+	tokens := tokenizePath(path)
+	if len(tokens) == 0 {
+		return Result{Type: TypeUndefined}
+	}
+	for i := range tokens {
+		switch tokens[i].kind {
+		case tokenRecursive, tokenWildcard, tokenArrayLength, tokenFilter, tokenQueryAll, tokenQueryUnion:
+			tokens[i].limit = limit
+		case tokenKey:
+			if tokens[i].glob {
+				tokens[i].limit = limit
+			}
+		}
+	}
 	return executeTokenizedPath(data, tokens)
 }
 
@@ -2560,6 +4336,8 @@ const (
 	tokenArrayLength // # for array length (when used alone)
 	tokenQueryFirst  // #(condition) for first match
 	tokenQueryAll    // #(condition)# for all matches
+	tokenSlice       // [start:end:step] array slice
+	tokenQueryUnion  // #(a)||#(b) for the union of several queries
 )
 
 // pathToken represents a single token in a parsed path
@@ -2568,25 +4346,48 @@ type pathToken struct {
 	str    string
 	num    int
 	filter *filterExpr
+	// union holds the query tokens of a tokenQueryUnion
+	union []pathToken
+	// glob marks a tokenKey whose str is a key pattern with unescaped * or ?;
+	// escapes are kept in str so \* and \? still match literally
+	glob bool
+	// limit caps the matches a collecting token gathers; see GetWithLimit
+	limit int
+	// merge makes a key token merge duplicate keys; see GetOptions
+	merge bool
+	// keyPath is set on a modifier written after a dot, as in obj.@keys. It
+	// is the rest of the path with the @ escaped, used instead when the
+	// object has a real "@keys" member.
+	keyPath string
 }
 
 type filterExpr struct {
 	path  string
 	op    string
 	value string
+	// valueRef is set when value names another field of the element,
+	// written with an @. prefix as in #(price>@.cost); value holds the path
+	valueRef bool
+	// set holds the literals of an in-list, as in #(status in [a,b])
+	set []string
+	// rest holds the conditions joined on with && or ||, evaluated left to
+	// right after this one
+	rest []filterClause
+}
+
+// filterClause is one condition of a compound query and the operator that
+// joins it to the conditions before it
+type filterClause struct {
+	join string
+	expr *filterExpr
 }
 
 // parseModifiers extracts and parses modifier tokens from a path.
 // Supports both legacy '|' and JSONPath-like '@' suffix modifiers.
-// For '@', only treat as a modifier separator when not inside strings/brackets/parentheses
-// and not immediately following a '.'. This preserves cases like "users.@length" as invalid
-// path segments and avoids interference with filter "@.field" usage.
-//
-// parseModifiers extracts and parses modifier tokens from a path.
-// Supports both legacy '|' and JSONPath-like '@' suffix modifiers.
-// For '@', only treat as a modifier separator when not inside strings/brackets/parentheses
-// and not immediately following a '.'. This preserves cases like "users.@length" as invalid
-// path segments and avoids interference with filter "@.field" usage.
+// For '@', only treat as a modifier separator when not inside strings/brackets/parentheses.
+// Directly after a '.', '@' only starts a modifier when it names a known one, so
+// "config.@length" applies @length while "obj.@id" still looks up the "@id" key.
+// Even then a real member such as "@type" wins over the modifier; see keyPath.
 //
 //go:inline
 func parseModifiers(path string) ([]pathToken, string, string) {
@@ -2603,11 +4404,14 @@ func parseModifiers(path string) ([]pathToken, string, string) {
 
 	// Extract suffix with modifiers and the main path
 	suffix := path[sepIdx+1:]
-	cleanPath := path[:sepIdx]
+	cleanPath := strings.TrimSuffix(path[:sepIdx], ".")
 
 	// Parse modifiers and remaining path from suffix
 	parts := splitModifierParts(suffix)
 	modifiers, remainingPath := parseModifierParts(parts)
+	if len(modifiers) > 0 && path[sepIdx] == '@' && sepIdx > 0 && path[sepIdx-1] == '.' {
+		modifiers[0].keyPath = `\` + path[sepIdx:]
+	}
 
 	return modifiers, cleanPath, remainingPath
 }
@@ -2671,10 +4475,28 @@ func isModifierSeparator(path string, i int, bracketDepth, parenDepth int) bool
 		if i == 0 || (i > 0 && path[i-1] != '.') {
 			return true
 		}
+		// After a dot, only a known modifier name counts (e.g. "obj.@length"),
+		// and only when a key path does not continue after it, as in "obj.@id.x"
+		name := modifierNameAt(path, i+1)
+		end := i + 1 + len(name)
+		if end < len(path) && path[end] == '.' && !strings.HasPrefix(path[end+1:], "@") {
+			return false
+		}
+		return isModifierName(name)
 	}
 	return false
 }
 
+// modifierNameAt returns the modifier name starting at i, up to the next
+// argument, chain or path separator.
+func modifierNameAt(path string, i int) string {
+	end := i
+	for end < len(path) && !strings.ContainsRune(":.|@", rune(path[end])) {
+		end++
+	}
+	return path[i:end]
+}
+
 // parseModifierParts parses split parts into modifiers and remaining path
 func parseModifierParts(parts []string) ([]pathToken, string) {
 	var modifiers []pathToken
@@ -2708,6 +4530,10 @@ func splitModifierParts(s string) []string {
 	var cur strings.Builder
 	for i := 0; i < len(s); i++ {
 		c := s[i]
+		if c == '.' && i+1 < len(s) && s[i+1] == '@' {
+			// "@keys.@length" chains like "@keys|@length"
+			continue
+		}
 		if c == '|' || c == '@' {
 			if cur.Len() > 0 {
 				parts = append(parts, cur.String())
@@ -2738,17 +4564,21 @@ func isModifierName(s string) bool {
 		"first": true, "last": true, "join": true, "sort": true,
 		"distinct": true, "unique": true, "length": true, "count": true, "len": true,
 		"type": true, "string": true, "str": true, "number": true, "num": true,
+		"int": true, "float": true,
 		"bool": true, "boolean": true, "base64": true, "base64decode": true,
 		"lower": true, "upper": true, "this": true, "valid": true,
-		"pretty": true, "ugly": true,
+		"pretty": true, "ugly": true, "commafy": true, "chunk": true, "sortKeys": true,
+		"diffAdjacent": true, "flattenKeys": true, "top": true, "bottom": true,
+		"trim": true, "trimPrefix": true, "trimSuffix": true, "csvcell": true,
 		// Aggregate modifiers
 		"sum": true, "avg": true, "average": true, "mean": true, "min": true, "max": true,
+		"describe": true,
 		// Advanced transformation modifiers
 		"group": true, "groupby": true, "sortby": true, "map": true, "project": true, "uniqueby": true,
 		// Additional jq-style modifiers
 		"slice": true, "has": true, "contains": true, "split": true,
 		"startswith": true, "endswith": true, "entries": true, "toentries": true,
-		"fromentries": true, "any": true, "all": true,
+		"fromentries": true, "toObject": true, "any": true, "all": true,
 	}
 
 	// Check built-in modifiers first
@@ -2766,15 +4596,17 @@ func parseArrayAccess(part string) []pathToken {
 
 	base := part[:strings.IndexByte(part, '[')]
 	if base != "" {
-		tokens = append(tokens, pathToken{kind: tokenKey, str: base})
+		tokens = append(tokens, pathToken{kind: tokenKey, str: base, glob: strings.ContainsAny(base, "*?")})
 	}
 
 	bracket := part[strings.IndexByte(part, '[')+1 : len(part)-1]
 
 	if bracket == "*" || bracket == "#" {
 		tokens = append(tokens, pathToken{kind: tokenWildcard})
+	} else if isSliceSpec(bracket) {
+		tokens = append(tokens, pathToken{kind: tokenSlice, str: bracket})
 	} else if idx, err := strconv.Atoi(bracket); err == nil {
-		tokens = append(tokens, pathToken{kind: tokenIndex, num: idx})
+		tokens = append(tokens, pathToken{kind: tokenIndex, num: idx, str: bracket})
 	} else if strings.HasPrefix(bracket, "?") || strings.Contains(bracket, "==") ||
 		strings.Contains(bracket, "!=") || strings.Contains(bracket, ">=") ||
 		strings.Contains(bracket, "<=") || strings.Contains(bracket, ">") ||
@@ -2782,17 +4614,38 @@ func parseArrayAccess(part string) []pathToken {
 		// Parse filter expression
 		filter := parseFilterExpression(bracket)
 		tokens = append(tokens, pathToken{kind: tokenFilter, filter: filter})
+	} else if strings.Contains(bracket, ":") {
+		// Malformed slice such as [0:10:x]; processSliceToken rejects it
+		tokens = append(tokens, pathToken{kind: tokenSlice, str: bracket})
 	}
 
 	return tokens
 }
 
+// isSliceSpec reports whether a bracket holds a start:end[:step] slice
+func isSliceSpec(bracket string) bool {
+	if strings.Count(bracket, ":") == 0 || strings.Count(bracket, ":") > 2 {
+		return false
+	}
+	for i := 0; i < len(bracket); i++ {
+		c := bracket[i]
+		if c != ':' && c != '-' && c != ' ' && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
 // tokenizePath breaks a path into tokens for efficient execution
 //
 //go:inline
 func tokenizePath(path string) []pathToken {
 	var tokens []pathToken
 
+	if union, ok := tokenizeQueryUnion(path); ok {
+		return union
+	}
+
 	// Check for modifiers (returns modifiers, clean path, and remaining path after modifiers)
 	modifiers, cleanPath, remainingPath := parseModifiers(path)
 
@@ -2814,6 +4667,77 @@ func tokenizePath(path string) []pathToken {
 	return tokens
 }
 
+// tokenizeQueryUnion tokenizes paths such as items.#(a==1)||#(b==2)#, where
+// queries joined by || select the union of their matches from the same
+// array. A single | keeps its pipe meaning, so items.#(a==1)#|#(b==2)#
+// filters the a==1 matches by b==2.
+func tokenizeQueryUnion(path string) ([]pathToken, bool) {
+	sep := findModifierSeparator(path)
+	if sep < 0 || path[sep] != '|' || !strings.HasPrefix(path[sep+1:], "|#(") {
+		return nil, false
+	}
+	tokens := tokenizePath(path[:sep])
+	if len(tokens) == 0 {
+		return nil, false
+	}
+	last := tokens[len(tokens)-1]
+	if last.kind != tokenQueryFirst && last.kind != tokenQueryAll {
+		return nil, false
+	}
+
+	union := []pathToken{last}
+	rest := path[sep+2:]
+	for {
+		end := queryExpressionEnd(rest)
+		if end < 0 {
+			return nil, false
+		}
+		union = append(union, parseQueryExpression(rest[:end])...)
+		rest = rest[end:]
+		if !strings.HasPrefix(rest, "||#(") {
+			break
+		}
+		rest = rest[2:]
+	}
+
+	tokens = append(tokens[:len(tokens)-1], pathToken{kind: tokenQueryUnion, union: union})
+	if rest = strings.TrimPrefix(strings.TrimPrefix(rest, "|"), "."); rest != "" {
+		tokens = append(tokens, tokenizePath(rest)...)
+	}
+	return tokens, true
+}
+
+// queryExpressionEnd returns the length of the #(condition) or #(condition)#
+// expression at the start of s, or -1 if its parentheses are unbalanced.
+func queryExpressionEnd(s string) int {
+	parenDepth := 0
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			parenDepth++
+		case c == ')':
+			parenDepth--
+			if parenDepth == 0 {
+				if i+1 < len(s) && s[i+1] == '#' {
+					return i + 2
+				}
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
 func splitPathSegments(path string) []string {
 	var parts []string
 	var cur strings.Builder
@@ -2895,8 +4819,13 @@ func shouldSplitAtDot(c byte, bracketDepth, parenDepth int) bool {
 // convertPartsToTokens converts string parts into pathTokens
 func convertPartsToTokens(parts []string) []pathToken {
 	var tokens []pathToken
-	for _, part := range parts {
+	for i, part := range parts {
 		if part == "" {
+			// An empty segment between two others comes from "a..b" and
+			// requests recursive descent; leading and trailing dots are ignored
+			if len(tokens) > 0 && i < len(parts)-1 && tokens[len(tokens)-1].kind != tokenRecursive {
+				tokens = append(tokens, pathToken{kind: tokenRecursive})
+			}
 			continue
 		}
 
@@ -2911,8 +4840,8 @@ func convertPartsToTokens(parts []string) []pathToken {
 			continue
 		}
 
-		// Check for query syntax: #(condition) or #(condition)#
-		if strings.HasPrefix(unescaped, "#(") {
+		// Check for query syntax: #(condition) or #(condition)#; \#( is a key
+		if strings.HasPrefix(part, "#(") {
 			queryTokens := parseQueryExpression(unescaped)
 			tokens = append(tokens, queryTokens...)
 			continue
@@ -2927,11 +4856,15 @@ func convertPartsToTokens(parts []string) []pathToken {
 		if strings.Contains(unescaped, "[") && strings.HasSuffix(unescaped, "]") {
 			arrayTokens := parseArrayAccess(unescaped)
 			tokens = append(tokens, arrayTokens...)
-		} else if idx, err := strconv.Atoi(unescaped); err == nil && idx >= 0 {
-			// Pure numeric token - treat as array index
-			tokens = append(tokens, pathToken{kind: tokenIndex, num: idx})
+		} else if idx, err := strconv.Atoi(unescaped); err == nil && (idx >= 0 || unescaped[0] == '-') {
+			// Pure numeric token - treat as array index; negative ones count
+			// from the end and keep their text for object lookups
+			tokens = append(tokens, pathToken{kind: tokenIndex, num: idx, str: unescaped})
+		} else if hasUnescapedWildcard(part) {
+			// Key pattern such as db_* or c?ildren
+			tokens = append(tokens, pathToken{kind: tokenKey, str: part, glob: true})
 		} else {
-			// Standard dot property
+			// Standard dot property; escaped \* and \? are literal characters
 			tokens = append(tokens, pathToken{kind: tokenKey, str: unescaped})
 		}
 	}
@@ -2970,8 +4903,55 @@ func parseQueryExpression(part string) []pathToken {
 	return []pathToken{{kind: tokenQueryFirst, filter: filter}}
 }
 
-// parseQueryCondition parses a query condition expression
+// parseQueryCondition parses a query condition expression. Conditions
+// joined by && or || are parsed one by one into a compound filter.
 func parseQueryCondition(condition string) *filterExpr {
+	parts, joins := splitFilterClauses(condition)
+	if len(parts) == 1 {
+		return parseQueryClause(condition)
+	}
+	filter := parseQueryClause(strings.TrimSpace(parts[0]))
+	for i, part := range parts[1:] {
+		filter.rest = append(filter.rest, filterClause{join: joins[i], expr: parseQueryClause(strings.TrimSpace(part))})
+	}
+	return filter
+}
+
+// splitFilterClauses splits condition at each && and || that is outside
+// strings and nested parentheses, returning the parts and the operators
+// between them
+func splitFilterClauses(condition string) (parts, joins []string) {
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(condition); i++ {
+		c := condition[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && i+1 < len(condition) && (c == '&' || c == '|') && condition[i+1] == c:
+			parts = append(parts, condition[start:i])
+			joins = append(joins, condition[i:i+2])
+			start = i + 2
+			i++
+		}
+	}
+	return append(parts, condition[start:]), joins
+}
+
+// parseQueryClause parses a single comparison such as age>30
+func parseQueryClause(condition string) *filterExpr {
 	// For nested queries like "nets.#(==\"fb\")", the condition is the entire path
 	// We need to find operators that are NOT inside parentheses
 
@@ -2982,12 +4962,19 @@ func parseQueryCondition(condition string) *filterExpr {
 		left := strings.TrimSpace(condition[:opIdx])
 		value := strings.TrimSpace(condition[opIdx+len(op):])
 
+		if op == constIn {
+			return &filterExpr{path: left, op: op, value: value, set: parseFilterSet(value)}
+		}
+
 		// Remove quotes from value if present
 		if len(value) >= 2 && ((value[0] == '"' && value[len(value)-1] == '"') ||
 			(value[0] == '\'' && value[len(value)-1] == '\'')) {
-			value = value[1 : len(value)-1]
+			return &filterExpr{path: left, op: op, value: value[1 : len(value)-1]}
 		}
 
+		if ref, ok := strings.CutPrefix(value, "@."); ok && ref != "" {
+			return &filterExpr{path: left, op: op, value: ref, valueRef: true}
+		}
 		return &filterExpr{path: left, op: op, value: value}
 	}
 
@@ -2996,6 +4983,63 @@ func parseQueryCondition(condition string) *filterExpr {
 	return &filterExpr{path: condition, op: ""}
 }
 
+// parseFilterSet splits a bracketed list such as [active,"on hold",3] into
+// its literals, removing quotes. A value without brackets is a single literal.
+func parseFilterSet(value string) []string {
+	if len(value) >= 2 && value[0] == '[' && value[len(value)-1] == ']' {
+		value = value[1 : len(value)-1]
+	}
+	var set []string
+	start := 0
+	var quote byte
+	for i := 0; i <= len(value); i++ {
+		if i < len(value) {
+			c := value[i]
+			switch {
+			case quote != 0 && c == '\\':
+				i++
+				continue
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '\'':
+				quote = c
+				continue
+			case c != ',':
+				continue
+			}
+		}
+		item := strings.TrimSpace(value[start:i])
+		if len(item) >= 2 && (item[0] == '"' || item[0] == '\'') && item[len(item)-1] == item[0] {
+			item = item[1 : len(item)-1]
+		}
+		if item != "" {
+			set = append(set, item)
+		}
+		start = i + 1
+	}
+	return set
+}
+
+// filterOperand returns the value to compare against. A field reference is
+// resolved against the element; ok is false if the field is missing or is
+// not a scalar.
+func filterOperand(value Result, filter *filterExpr) (string, bool) {
+	if !filter.valueRef {
+		return filter.value, true
+	}
+	ref := value.Get(filter.value)
+	switch ref.Type {
+	case TypeString:
+		return ref.Str, true
+	case TypeNumber, TypeBoolean, TypeNull:
+		return string(ref.Raw), true
+	}
+	return "", false
+}
+
 // findQueryOperator finds the query operator in the condition string
 func findQueryOperator(condition string) (string, int) {
 	// Track parentheses depth
@@ -3042,11 +5086,24 @@ func findQueryOperator(condition string) (string, int) {
 			if op, found := checkQueryOperator(condition, i, c, possibleOps); found {
 				return op, i
 			}
+			if isInOperator(condition, i) {
+				return constIn, i
+			}
 		}
 	}
 	return "", -1
 }
 
+// isInOperator reports whether the word "in" starts at i and stands on its
+// own, as in "status in [a,b]"
+func isInOperator(condition string, i int) bool {
+	if !strings.HasPrefix(condition[i:], constIn) || (i > 0 && condition[i-1] != ' ') {
+		return false
+	}
+	next := i + len(constIn)
+	return next < len(condition) && (condition[next] == ' ' || condition[next] == '[')
+}
+
 // checkQueryOperator checks if an operator starts at current position
 func checkQueryOperator(condition string, i int, c byte, possibleOps []string) (string, bool) {
 	// Check longest operators first to avoid partial matches
@@ -3130,6 +5187,7 @@ func executeTokenizedPath(data []byte, tokens []pathToken) Result {
 	// Process tokens before modifiers
 	for i, token := range before {
 		result, shouldReturn := processPathToken(current, token, before, i, hasModifiers)
+		result = limitArrayResult(result, token.limit)
 		if shouldReturn {
 			current = result
 			break
@@ -3142,6 +5200,13 @@ func executeTokenizedPath(data []byte, tokens []pathToken) Result {
 
 	// Apply modifiers if any
 	if len(modifiers) > 0 {
+		if keyPath := modifiers[0].keyPath; keyPath != "" && current.Type == TypeObject {
+			// obj.@type reads a real "@type" member rather than the modifier
+			name, _, _ := strings.Cut(modifiers[0].str, ":")
+			if start, _ := fastFindObjectValue(current.Raw, "@"+name); start != -1 {
+				return getComplexPath(current.Raw, keyPath)
+			}
+		}
 		current = applyModifiersToResult(current, modifiers)
 		if !current.Exists() {
 			return Result{Type: TypeUndefined}
@@ -3152,6 +5217,7 @@ func executeTokenizedPath(data []byte, tokens []pathToken) Result {
 	if len(after) > 0 {
 		for i, token := range after {
 			result, shouldReturn := processPathToken(current, token, after, i, false)
+			result = limitArrayResult(result, token.limit)
 			if shouldReturn {
 				current = result
 				break
@@ -3197,13 +5263,18 @@ func processPathToken(current Result, token pathToken, pathTokens []pathToken, i
 		if current.Type == TypeArray && i > 0 {
 			prev := pathTokens[i-1]
 			if prev.kind == tokenWildcard || prev.kind == tokenFilter || prev.kind == tokenArrayLength ||
-				prev.kind == tokenQueryAll {
+				prev.kind == tokenQueryAll || prev.kind == tokenQueryUnion {
 				return processArrayProjection(current, pathTokens, i)
 			}
 		}
+		if current.Type == TypeObject && token.glob {
+			return processKeyPattern(current, token.str, pathTokens, i)
+		}
 		return processKeyToken(current, token)
 	case tokenIndex:
 		return processIndexToken(current, token)
+	case tokenSlice:
+		return processSliceToken(current, token)
 	case tokenWildcard:
 		return processWildcardToken(current, pathTokens, i)
 	case tokenArrayLength:
@@ -3214,6 +5285,8 @@ func processPathToken(current Result, token pathToken, pathTokens []pathToken, i
 		return processQueryFirstToken(current, token)
 	case tokenQueryAll:
 		return processQueryAllToken(current, token)
+	case tokenQueryUnion:
+		return processQueryUnionToken(current, token)
 	case tokenRecursive:
 		return processRecursiveToken(current, pathTokens, i)
 	default:
@@ -3228,11 +5301,9 @@ func processKeyToken(current Result, token pathToken) (Result, bool) {
 	}
 
 	key := token.str
-
-	// Check if key contains pattern characters (* or ?)
-	if strings.ContainsAny(key, "*?") {
-		// Pattern matching on keys
-		return processKeyPattern(current, key)
+	if token.merge {
+		result := findMergedMember(current.Raw, key)
+		return result, !result.Exists()
 	}
 
 	// Use direct object lookup instead of ForEach to avoid allocations
@@ -3244,24 +5315,27 @@ func processKeyToken(current Result, token pathToken) (Result, bool) {
 	return fastParseValue(current.Raw[start:end]), false
 }
 
-// processKeyPattern handles pattern matching on object keys (e.g., child*, c?ildren)
-func processKeyPattern(current Result, pattern string) (Result, bool) {
-	var matchedValue Result
-	found := false
-
+// processKeyPattern handles pattern matching on object keys (e.g., db_*, c?ildren).
+// A single matching key yields its value; several behave like the * wildcard
+// restricted to the matching keys, collecting their values into an array.
+func processKeyPattern(current Result, pattern string, pathTokens []pathToken, i int) (Result, bool) {
+	var values []Result
 	current.ForEach(func(key, value Result) bool {
-		if matchPattern(key.Str, pattern) {
-			matchedValue = value
-			found = true
-			return false // Stop after first match
+		if matchKeyPattern(key.Str, pattern) {
+			values = append(values, value)
 		}
 		return true
 	})
 
-	if !found {
+	switch {
+	case len(values) == 0:
 		return Result{Type: TypeUndefined}, true
+	case len(values) == 1:
+		return values[0], false
+	case i == len(pathTokens)-1:
+		return buildArrayResult(values), false
 	}
-	return matchedValue, false
+	return processRemainingTokensForWildcard(values, pathTokens, i)
 }
 
 // matchPattern matches a string against a glob pattern with * (any chars) and ? (single char)
@@ -3303,11 +5377,55 @@ func matchPatternHelper(s, pattern string, si, pi int) bool {
 	return si == len(s)
 }
 
+// matchKeyPattern is matchPattern for key patterns in paths, where a
+// backslash makes the next character literal, as in a\*b
+func matchKeyPattern(s, pattern string) bool {
+	for pi := 0; pi < len(pattern); pi++ {
+		switch pattern[pi] {
+		case '*':
+			for si := 0; si <= len(s); si++ {
+				if matchKeyPattern(s[si:], pattern[pi+1:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+			s = s[1:]
+		case '\\':
+			if pi+1 < len(pattern) {
+				pi++
+			}
+			fallthrough
+		default:
+			if s == "" || s[0] != pattern[pi] {
+				return false
+			}
+			s = s[1:]
+		}
+	}
+	return s == ""
+}
+
 // processIndexToken handles array index access
 func processIndexToken(current Result, token pathToken) (Result, bool) {
+	if token.num < 0 && current.Type == TypeObject && token.str != "" {
+		// A negative number is an ordinary key on objects
+		return processKeyToken(current, pathToken{kind: tokenKey, str: token.str})
+	}
 	if current.Type != TypeArray {
 		return Result{Type: TypeUndefined}, true
 	}
+	if token.num < 0 {
+		start := bytes.IndexByte(current.Raw, '[')
+		idx := token.num + countArrayElements(current.Raw, start+1)
+		if idx < 0 {
+			return Result{Type: TypeUndefined}, true
+		}
+		token.num = idx
+	}
 
 	// Use direct array lookup instead of Array() to avoid allocations
 	start, end := fastFindArrayElement(current.Raw, token.num)
@@ -3318,6 +5436,14 @@ func processIndexToken(current Result, token pathToken) (Result, bool) {
 	return fastParseValue(current.Raw[start:end]), false
 }
 
+// processSliceToken selects a [start:end:step] range of an array
+func processSliceToken(current Result, token pathToken) (Result, bool) {
+	if current.Type != TypeArray || !isSliceSpec(token.str) {
+		return Result{Type: TypeUndefined}, true
+	}
+	return buildArrayResult(sliceResults(current.Array(), token.str)), false
+}
+
 // processWildcardToken handles wildcard access
 func processWildcardToken(current Result, pathTokens []pathToken, i int) (Result, bool) {
 	if current.Type != TypeArray && current.Type != TypeObject {
@@ -3466,11 +5592,16 @@ func skipQuotedString(data []byte, pos int) int {
 
 // processWildcardCollection handles wildcard collection processing
 func processWildcardCollection(current Result, pathTokens []pathToken, i int) (Result, bool) {
-	// Collect all values with minimal allocations
+	// Collect all values with minimal allocations; a limited last token
+	// needs no more than its limit
+	limit := 0
+	if i == len(pathTokens)-1 {
+		limit = pathTokens[i].limit
+	}
 	values := make([]Result, 0, 8) // Pre-allocate for common case
 	current.ForEach(func(_, value Result) bool {
 		values = append(values, value)
-		return true
+		return limit <= 0 || len(values) < limit
 	})
 
 	if len(values) == 0 {
@@ -3651,15 +5782,50 @@ func processQueryAllToken(current Result, token pathToken) (Result, bool) {
 		return Result{Type: TypeUndefined}, true
 	}
 
-	// Find all matches
+	// Find all matches, up to the limit set by GetWithLimit
 	var matches []Result
 	current.ForEach(func(_, value Result) bool {
 		if matchesQueryCondition(value, token.filter) {
 			matches = append(matches, value)
 		}
+		return token.limit <= 0 || len(matches) < token.limit
+	})
+
+	return buildMatchedArrayResult(matches)
+}
+
+// processQueryUnionToken collects the elements matched by any of the union's
+// queries, each element at most once and in array order.
+func processQueryUnionToken(current Result, token pathToken) (Result, bool) {
+	if current.Type != TypeArray {
+		return Result{Type: TypeUndefined}, true
+	}
+
+	var elements []Result
+	current.ForEach(func(_, value Result) bool {
+		elements = append(elements, value)
 		return true
 	})
 
+	picked := make([]bool, len(elements))
+	for _, query := range token.union {
+		for i, value := range elements {
+			if !matchesQueryCondition(value, query.filter) {
+				continue
+			}
+			picked[i] = true
+			if query.kind == tokenQueryFirst {
+				break
+			}
+		}
+	}
+
+	var matches []Result
+	for i, value := range elements {
+		if picked[i] {
+			matches = append(matches, value)
+		}
+	}
 	return buildMatchedArrayResult(matches)
 }
 
@@ -3686,8 +5852,21 @@ func buildMatchedArrayResult(matches []Result) (Result, bool) {
 	}, false
 }
 
-// matchesQueryCondition checks if a value matches a query condition
+// matchesQueryCondition checks if a value matches a query condition.
+// Compound conditions are evaluated left to right with no precedence, and a
+// clause is skipped once its && or || cannot change the outcome.
 func matchesQueryCondition(value Result, filter *filterExpr) bool {
+	matched := matchesQueryClause(value, filter)
+	for _, clause := range filter.rest {
+		if (clause.join == "&&") == matched {
+			matched = matchesQueryClause(value, clause.expr)
+		}
+	}
+	return matched
+}
+
+// matchesQueryClause checks a single comparison of a query condition
+func matchesQueryClause(value Result, filter *filterExpr) bool {
 	// Get the value to filter on
 	var filterValue Result
 	if filter.path == "" {
@@ -3704,27 +5883,37 @@ func matchesQueryCondition(value Result, filter *filterExpr) bool {
 	if filter.op == "" {
 		return true
 	}
+	operand, ok := filterOperand(value, filter)
+	if !ok {
+		return false
+	}
 
 	// Compare based on operator
 	switch filter.op {
 	case "=", constEq:
-		return compareEqual(filterValue, filter.value)
+		return compareEqual(filterValue, operand)
 	case "!=":
-		return !compareEqual(filterValue, filter.value)
+		return !compareEqual(filterValue, operand)
 	case ">":
-		return compareGreater(filterValue, filter.value)
+		return compareGreater(filterValue, operand)
 	case "<":
-		return compareLess(filterValue, filter.value)
+		return compareLess(filterValue, operand)
 	case ">=":
-		return compareGreaterEqual(filterValue, filter.value)
+		return compareGreaterEqual(filterValue, operand)
 	case "<=":
-		return compareLessEqual(filterValue, filter.value)
+		return compareLessEqual(filterValue, operand)
 	case "%":
 		// Pattern matching
-		return matchPattern(filterValue.String(), filter.value)
+		return matchPattern(filterValue.String(), operand)
 	case "!%":
 		// Negative pattern matching
-		return !matchPattern(filterValue.String(), filter.value)
+		return !matchPattern(filterValue.String(), operand)
+	case constIn:
+		for _, item := range filter.set {
+			if compareEqual(filterValue, item) {
+				return true
+			}
+		}
 	}
 
 	return false
@@ -3737,8 +5926,8 @@ func processRecursiveToken(current Result, pathTokens []pathToken, i int) (Resul
 		return Result{Type: TypeUndefined}, true
 	}
 
-	// Recursive descent
-	result := recursiveSearch(current, pathTokens[i+1:])
+	// Recursive descent, stopping early under GetWithLimit
+	result := recursiveSearch(current, pathTokens[i+1:], pathTokens[i].limit)
 	return result, true // recursiveSearch processes the rest of the tokens
 }
 
@@ -3878,69 +6067,80 @@ func compareLessEqual(result Result, value string) bool {
 	}
 }
 
-// processRecursiveMatches processes recursive search matches for both objects and arrays
-func processRecursiveMatches(current Result, remainingTokens []pathToken) []Result {
-	var matches []Result
-	current.ForEach(func(_, value Result) bool {
-		// Try this value with remaining tokens
-		subResult := executeTokenizedPath(value.Raw, remainingTokens)
-		if subResult.Exists() {
-			matches = append(matches, subResult)
-		}
-
-		// Continue recursion for objects and arrays
-		if value.Type == TypeObject || value.Type == TypeArray {
-			subMatches := recursiveSearch(value, remainingTokens)
-			if subMatches.Exists() {
-				if subMatches.Type == TypeArray {
-					// Add all items from array
-					subMatches.ForEach(func(_, item Result) bool {
-						matches = append(matches, item)
-						return true
-					})
-				} else {
-					matches = append(matches, subMatches)
-				}
-			}
+// processRecursiveMatches applies remainingTokens at current and at every
+// value nested beneath it, appending each match in document order. It stops
+// descending once limit matches are collected; a limit <= 0 means no limit.
+func processRecursiveMatches(current Result, remainingTokens []pathToken, matches []Result, limit int) []Result {
+	// Keys only resolve against objects; arrays are descended into instead
+	if current.Type != TypeArray || remainingTokens[0].kind != tokenKey {
+		if sub := executeTokenizedPath(current.Raw, remainingTokens); sub.Exists() {
+			matches = append(matches, sub)
 		}
-		return true
-	})
+	}
+
+	if current.Type == TypeObject || current.Type == TypeArray {
+		current.ForEach(func(_, value Result) bool {
+			matches = processRecursiveMatches(value, remainingTokens, matches, limit)
+			return limit <= 0 || len(matches) < limit
+		})
+	}
 	return matches
 }
 
-// recursiveSearch searches recursively through a JSON structure
-func recursiveSearch(current Result, remainingTokens []pathToken) Result {
+// recursiveSearch searches recursively through a JSON structure and returns
+// every match as an array. A first-match query such as #(price<10) keeps its
+// meaning and yields the first match in document order only. When the tokens after the first
+// one project over an array (#(...)#, *), each match's elements are spliced
+// into the result so that store..book.#(price<10)# yields a flat list.
+func recursiveSearch(current Result, remainingTokens []pathToken, limit int) Result {
 	// End of path, return current
 	if len(remainingTokens) == 0 {
 		return current
 	}
 
-	// Try direct match first
-	direct := executeTokenizedPath(current.Raw, remainingTokens)
-	if direct.Exists() {
-		return direct
+	flatten, first := false, false
+	for _, token := range remainingTokens[1:] {
+		switch token.kind {
+		case tokenQueryAll, tokenQueryUnion, tokenWildcard:
+			flatten = true
+		case tokenQueryFirst:
+			first = true
+		}
 	}
-
-	// Collect results from recursive descent
-	var matches []Result
-
-	switch current.Type {
-	case TypeObject, TypeArray:
-		matches = processRecursiveMatches(current, remainingTokens)
+	if first && !flatten {
+		limit = 1
 	}
 
+	matches := processRecursiveMatches(current, remainingTokens, nil, limit)
 	if len(matches) == 0 {
 		return Result{Type: TypeUndefined}
 	}
+	if first && !flatten {
+		return matches[0]
+	}
 
-	// Return array of matches
 	var raw bytes.Buffer
 	raw.WriteByte('[')
-	for i, val := range matches {
-		if i > 0 {
+	n := 0
+	write := func(val Result) bool {
+		if limit > 0 && n >= limit {
+			return false
+		}
+		if n > 0 {
 			raw.WriteByte(',')
 		}
 		raw.Write(val.Raw)
+		n++
+		return true
+	}
+	for _, val := range matches {
+		if flatten && val.Type == TypeArray {
+			val.ForEach(func(_, item Result) bool {
+				return write(item)
+			})
+			continue
+		}
+		write(val)
 	}
 	raw.WriteByte(']')
 
@@ -3993,8 +6193,10 @@ func applyTypeConversionModifier(result Result, name string) (Result, bool) {
 	switch name {
 	case constString, "str":
 		return applyStringModifier(result), true
-	case constNumber, "num":
+	case constNumber, "num", "float":
 		return applyNumberModifier(result), true
+	case "int":
+		return applyIntModifier(result), true
 	case constBool, constBoolean:
 		return applyBooleanModifier(result), true
 	case "type":
@@ -4026,6 +6228,14 @@ func applyCollectionModifier(result Result, name, arg string) (Result, bool) {
 		return applyLastModifier(result), true
 	case "join":
 		return applyJoinModifier(result, arg), true
+	case "csvcell":
+		return applyCSVCellModifier(result, arg), true
+	case "chunk":
+		return applyChunkModifier(result, arg), true
+	case "diffAdjacent":
+		return applyDiffAdjacentModifier(result), true
+	case "top", "bottom":
+		return applyTopModifier(result, arg, name == "top"), true
 	}
 	return Result{}, false
 }
@@ -4041,6 +6251,8 @@ func applyAggregateModifier(result Result, name string) (Result, bool) {
 		return applyMinModifier(result), true
 	case "max":
 		return applyMaxModifier(result), true
+	case "describe":
+		return applyDescribeModifier(result), true
 	}
 	return Result{}, false
 }
@@ -4056,6 +6268,8 @@ func applyFormattingModifier(result Result, name, arg string) (Result, bool) {
 		return applyLowerModifier(result), true
 	case "upper":
 		return applyUpperModifier(result), true
+	case "trim", "trimPrefix", "trimSuffix":
+		return applyTrimModifier(result, name, arg), true
 	case "this":
 		return applyThisModifier(result), true
 	case "valid":
@@ -4064,6 +6278,12 @@ func applyFormattingModifier(result Result, name, arg string) (Result, bool) {
 		return applyPrettyModifier(result, arg), true
 	case "ugly":
 		return applyUglyModifier(result), true
+	case "commafy":
+		return applyCommafyModifier(result, arg), true
+	case "sortKeys":
+		return applySortKeysModifier(result), true
+	case "flattenKeys":
+		return applyFlattenKeysModifier(result), true
 	}
 	return Result{}, false
 }
@@ -4100,7 +6320,7 @@ func applyJQStyleModifier(result Result, name, arg string) (Result, bool) {
 		return applyEndsWithModifier(result, arg), true
 	case "entries", "toentries":
 		return applyEntriesToModifier(result), true
-	case "fromentries":
+	case "fromentries", "toObject":
 		return applyFromEntriesModifier(result), true
 	case "any":
 		return applyAnyModifier(result), true
@@ -4131,6 +6351,17 @@ func applyNumberModifier(result Result) Result {
 	}
 }
 
+// applyIntModifier converts result to an integer number using Int
+func applyIntModifier(result Result) Result {
+	n := result.Int()
+	return Result{
+		Type:     TypeNumber,
+		Num:      float64(n),
+		Raw:      []byte(strconv.FormatInt(n, 10)),
+		Modified: true,
+	}
+}
+
 // applyBooleanModifier converts result to boolean type
 func applyBooleanModifier(result Result) Result {
 	b := result.Bool()
@@ -4148,8 +6379,12 @@ func applyBooleanModifier(result Result) Result {
 	}
 }
 
-// applyKeysModifier extracts object keys as array
+// applyKeysModifier extracts object keys as array; for an array it returns
+// the element indexes
 func applyKeysModifier(result Result) Result {
+	if result.Type == TypeArray {
+		return arrayIndexesResult(result)
+	}
 	if result.Type != TypeObject {
 		return Result{Type: TypeUndefined}
 	}
@@ -4183,8 +6418,33 @@ func applyKeysModifier(result Result) Result {
 	}
 }
 
-// applyValuesModifier extracts object values as array
+// arrayIndexesResult builds the index list [0,1,...] of an array
+func arrayIndexesResult(result Result) Result {
+	raw := []byte{'['}
+	n := 0
+	result.ForEach(func(_, _ Result) bool {
+		if n > 0 {
+			raw = append(raw, ',')
+		}
+		raw = strconv.AppendInt(raw, int64(n), 10)
+		n++
+		return true
+	})
+	raw = append(raw, ']')
+
+	return Result{
+		Type:     TypeArray,
+		Raw:      raw,
+		Modified: true,
+	}
+}
+
+// applyValuesModifier extracts object values as array; an array is
+// returned as is
 func applyValuesModifier(result Result) Result {
+	if result.Type == TypeArray {
+		return result
+	}
 	if result.Type != TypeObject {
 		return Result{Type: TypeUndefined}
 	}
@@ -4251,29 +6511,32 @@ func buildCountResult(count int) Result {
 
 // applyTypeModifier returns the type of the result as string
 func applyTypeModifier(result Result) Result {
-	var typeStr string
-	switch result.Type {
+	typeStr := typeName(result.Type)
+	return Result{
+		Type:     TypeString,
+		Str:      typeStr,
+		Raw:      []byte(`"` + typeStr + `"`),
+		Modified: true,
+	}
+}
+
+// typeName returns the JSON name of a value type, as reported by @type
+func typeName(t ValueType) string {
+	switch t {
 	case TypeString:
-		typeStr = constString
+		return constString
 	case TypeNumber:
-		typeStr = constNumber
+		return constNumber
 	case TypeBoolean:
-		typeStr = constBoolean
+		return constBoolean
 	case TypeObject:
-		typeStr = "object"
+		return "object"
 	case TypeArray:
-		typeStr = "array"
+		return "array"
 	case TypeNull:
-		typeStr = constNull
+		return constNull
 	default:
-		typeStr = "undefined"
-	}
-
-	return Result{
-		Type:     TypeString,
-		Str:      typeStr,
-		Raw:      []byte(`"` + typeStr + `"`),
-		Modified: true,
+		return "undefined"
 	}
 }
 
@@ -4319,7 +6582,31 @@ func applyLowerModifier(result Result) Result {
 			Modified: true,
 		}
 	}
-	return result
+	return result
+}
+
+// applyTrimModifier trims string results: @trim removes surrounding
+// whitespace, @trimPrefix:s and @trimSuffix:s remove s from one end.
+// Example: name.@trim or url|@trimSuffix:/
+func applyTrimModifier(result Result, name, arg string) Result {
+	if result.Type != TypeString {
+		return result
+	}
+	var trimmed string
+	switch name {
+	case "trimPrefix":
+		trimmed = strings.TrimPrefix(result.Str, arg)
+	case "trimSuffix":
+		trimmed = strings.TrimSuffix(result.Str, arg)
+	default:
+		trimmed = strings.TrimSpace(result.Str)
+	}
+	return Result{
+		Type:     TypeString,
+		Str:      trimmed,
+		Raw:      []byte(`"` + escapeString(trimmed) + `"`),
+		Modified: true,
+	}
 }
 
 // applyUpperModifier converts string to uppercase
@@ -4336,6 +6623,51 @@ func applyUpperModifier(result Result) Result {
 	return result
 }
 
+// applyCommafyModifier formats a number with thousands separators.
+// The separator defaults to ","; with "." the decimal mark becomes ",".
+// Example: revenue|@commafy turns 1234567.5 into "1,234,567.5"
+func applyCommafyModifier(result Result, sep string) Result {
+	if result.Type != TypeNumber {
+		return result
+	}
+	if sep == "" {
+		sep = ","
+	}
+	decimal := "."
+	if sep == "." {
+		decimal = ","
+	}
+
+	digits := strconv.FormatFloat(result.Num, 'f', -1, 64)
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	intPart, fracPart, hasFrac := strings.Cut(digits, ".")
+
+	var sb strings.Builder
+	sb.Grow(len(digits) + len(digits)/3*len(sep) + 1)
+	sb.WriteString(sign)
+	for i := 0; i < len(intPart); i++ {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteByte(intPart[i])
+	}
+	if hasFrac {
+		sb.WriteString(decimal)
+		sb.WriteString(fracPart)
+	}
+
+	str := sb.String()
+	return Result{
+		Type:     TypeString,
+		Str:      str,
+		Raw:      []byte(`"` + escapeString(str) + `"`),
+		Modified: true,
+	}
+}
+
 // applyJoinModifier joins array elements with separator
 func applyJoinModifier(result Result, arg string) Result {
 	if result.Type == TypeArray {
@@ -4361,6 +6693,32 @@ func applyJoinModifier(result Result, arg string) Result {
 	return result
 }
 
+// applyCSVCellModifier joins an array like @join and escapes the text as one
+// CSV field: it is quoted, with inner quotes doubled, when it holds a comma,
+// quote or line break. Other values are escaped as is and null is empty.
+func applyCSVCellModifier(result Result, arg string) Result {
+	var cell string
+	switch result.Type {
+	case TypeArray:
+		cell = applyJoinModifier(result, arg).Str
+	case TypeUndefined:
+		return result
+	case TypeNull:
+	default:
+		cell = result.String()
+	}
+
+	if strings.ContainsAny(cell, ",\"\r\n") {
+		cell = `"` + strings.ReplaceAll(cell, `"`, `""`) + `"`
+	}
+	return Result{
+		Type:     TypeString,
+		Str:      cell,
+		Raw:      []byte(`"` + escapeString(cell) + `"`),
+		Modified: true,
+	}
+}
+
 // applyReverseModifier reverses array elements order
 func applyReverseModifier(result Result) Result {
 	if result.Type != TypeArray {
@@ -4383,6 +6741,111 @@ func applyReverseModifier(result Result) Result {
 	return reversed
 }
 
+// applyChunkModifier splits an array into sub-arrays of at most size elements.
+// Example: items.@chunk:100
+func applyChunkModifier(result Result, arg string) Result {
+	if result.Type != TypeArray {
+		return result
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || size <= 0 {
+		return result
+	}
+
+	var chunks, current []Result
+	result.ForEach(func(_, value Result) bool {
+		current = append(current, value)
+		if len(current) == size {
+			chunks = append(chunks, buildArrayResult(current))
+			current = nil
+		}
+		return true
+	})
+	if len(current) > 0 {
+		chunks = append(chunks, buildArrayResult(current))
+	}
+	chunked := buildArrayResult(chunks)
+	chunked.Modified = true
+	return chunked
+}
+
+// applyTopModifier returns the k largest (or smallest) numbers of an array,
+// sorted from the extreme inward. With a key, as in @top:3:score, elements
+// are objects ranked by that field. Elements without a number are skipped.
+// Example: scores|@top:3
+func applyTopModifier(result Result, arg string, largest bool) Result {
+	if result.Type != TypeArray {
+		return Result{Type: TypeUndefined}
+	}
+	countArg, key, _ := strings.Cut(arg, ":")
+	k, err := strconv.Atoi(strings.TrimSpace(countArg))
+	if err != nil || k < 0 {
+		return Result{Type: TypeUndefined}
+	}
+
+	type ranked struct {
+		value Result
+		num   float64
+	}
+	var items []ranked
+	result.ForEach(func(_, value Result) bool {
+		field := value
+		if key != "" {
+			field = value.Get(key)
+		}
+		if num, ok := numericValue(field); ok {
+			items = append(items, ranked{value, num})
+		}
+		return true
+	})
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if largest {
+			return items[i].num > items[j].num
+		}
+		return items[i].num < items[j].num
+	})
+	if len(items) > k {
+		items = items[:k]
+	}
+
+	values := make([]Result, len(items))
+	for i, item := range items {
+		values[i] = item.value
+	}
+	top := buildArrayResult(values)
+	top.Modified = true
+	return top
+}
+
+// applyDiffAdjacentModifier returns the differences between consecutive
+// numbers of an array; non-numeric elements are skipped.
+// Example: [1,3,6,10]|@diffAdjacent returns [2,3,4]
+func applyDiffAdjacentModifier(result Result) Result {
+	if result.Type != TypeArray {
+		return Result{Type: TypeUndefined}
+	}
+
+	var diffs []Result
+	var prev float64
+	seen := false
+	result.ForEach(func(_, value Result) bool {
+		num, ok := numericValue(value)
+		if !ok {
+			return true
+		}
+		if seen {
+			diffs = append(diffs, buildNumberResult(num-prev))
+		}
+		prev, seen = num, true
+		return true
+	})
+
+	diffed := buildArrayResult(diffs)
+	diffed.Modified = true
+	return diffed
+}
+
 func applyFlattenModifier(result Result) Result {
 	if result.Type != TypeArray {
 		return result
@@ -4442,7 +6905,10 @@ func applySortModifier(result Result, arg string) Result {
 	sorted := make([]Result, len(items))
 	copy(sorted, items)
 
-	descending := strings.EqualFold(arg, "desc") || strings.EqualFold(arg, "descending") || strings.EqualFold(arg, "reverse")
+	key, descending := parseSortArg(arg)
+	if key != "" {
+		return sortByKey(sorted, key, descending)
+	}
 
 	allNumbers := true
 	for _, item := range sorted {
@@ -4476,6 +6942,66 @@ func applySortModifier(result Result, arg string) Result {
 	return sortedResult
 }
 
+// parseSortArg splits a @sort argument into an optional key and direction,
+// as in "desc", "age" or "age:desc"
+func parseSortArg(arg string) (key string, descending bool) {
+	key = arg
+	if i := strings.LastIndexByte(arg, ':'); i >= 0 {
+		key = arg[:i]
+		descending = isSortDescending(arg[i+1:])
+	} else if isSortDescending(arg) || strings.EqualFold(arg, "asc") || strings.EqualFold(arg, "ascending") {
+		return "", isSortDescending(arg)
+	}
+	return key, descending
+}
+
+func isSortDescending(dir string) bool {
+	return strings.EqualFold(dir, "desc") || strings.EqualFold(dir, "descending") || strings.EqualFold(dir, "reverse")
+}
+
+// sortByKey stably sorts elements by their key field (@sort:key[:desc]).
+// Keys compare numerically if the first element that has the key holds a
+// number and as strings otherwise. Elements without the key go last.
+func sortByKey(items []Result, key string, descending bool) Result {
+	values := make([]Result, len(items))
+	numeric, typed := false, false
+	for i, item := range items {
+		values[i] = item.Get(key)
+		if !typed && values[i].Exists() {
+			numeric, typed = values[i].Type == TypeNumber, true
+		}
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		va, vb := values[order[a]], values[order[b]]
+		if !va.Exists() || !vb.Exists() {
+			return va.Exists() && !vb.Exists()
+		}
+		if numeric {
+			if descending {
+				return va.Float() > vb.Float()
+			}
+			return va.Float() < vb.Float()
+		}
+		if descending {
+			return va.String() > vb.String()
+		}
+		return va.String() < vb.String()
+	})
+
+	sorted := make([]Result, len(items))
+	for i, idx := range order {
+		sorted[i] = items[idx]
+	}
+	result := buildArrayResult(sorted)
+	result.Modified = true
+	return result
+}
+
 func applyFirstModifier(result Result) Result {
 	if result.Type != TypeArray {
 		return result
@@ -4607,11 +7133,105 @@ func applyMinMaxModifier(result Result, findMin bool) Result {
 	return buildNumberResult(m)
 }
 
+// applyDescribeModifier computes count, min, max, sum and avg of the numeric
+// elements of an array in a single pass.
+// Example: scores|@describe returns {"count":3,"min":1,"max":3,"sum":6,"avg":2}
+func applyDescribeModifier(result Result) Result {
+	if result.Type != TypeArray {
+		return Result{Type: TypeUndefined}
+	}
+
+	var sum, lo, hi float64
+	count := 0
+	result.ForEach(func(_, value Result) bool {
+		num, ok := numericValue(value)
+		if !ok {
+			return true
+		}
+		if count == 0 || num < lo {
+			lo = num
+		}
+		if count == 0 || num > hi {
+			hi = num
+		}
+		sum += num
+		count++
+		return true
+	})
+
+	raw := make([]byte, 0, 64)
+	raw = append(raw, `{"count":`...)
+	raw = strconv.AppendInt(raw, int64(count), 10)
+	if count == 0 {
+		raw = append(raw, `,"min":null,"max":null,"sum":null,"avg":null}`...)
+	} else {
+		raw = append(raw, `,"min":`...)
+		raw = strconv.AppendFloat(raw, lo, 'f', -1, 64)
+		raw = append(raw, `,"max":`...)
+		raw = strconv.AppendFloat(raw, hi, 'f', -1, 64)
+		raw = append(raw, `,"sum":`...)
+		raw = strconv.AppendFloat(raw, sum, 'f', -1, 64)
+		raw = append(raw, `,"avg":`...)
+		raw = strconv.AppendFloat(raw, sum/float64(count), 'f', -1, 64)
+		raw = append(raw, '}')
+	}
+
+	return Result{Type: TypeObject, Raw: raw, Modified: true}
+}
+
 // ==================== ADVANCED TRANSFORMATION MODIFIERS ====================
 
+// zipObjectArrays turns an object of parallel arrays into an array of objects,
+// {"id":[1,2],"name":["a","b"]} into [{"id":1,"name":"a"},{"id":2,"name":"b"}].
+// Members that are not arrays are skipped and the shortest array sets the
+// length.
+func zipObjectArrays(result Result) Result {
+	var keys [][]byte
+	var columns [][]Result
+	result.ForEach(func(key, value Result) bool {
+		if value.Type == TypeArray {
+			keys = append(keys, key.Raw)
+			columns = append(columns, value.Array())
+		}
+		return true
+	})
+
+	n := 0
+	for i, column := range columns {
+		if i == 0 || len(column) < n {
+			n = len(column)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for row := 0; row < n; row++ {
+		if row > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for i, column := range columns {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			buf.Write(column[row].Raw)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	return Result{Type: TypeArray, Raw: buf.Bytes(), Modified: true}
+}
+
 // applyGroupModifier groups array elements by a field value
 // Example: users|@group:city returns {"NYC": [...], "Boston": [...]}
+// Without a field, an object of parallel arrays is zipped instead.
 func applyGroupModifier(result Result, field string) Result {
+	if result.Type == TypeObject && field == "" {
+		return zipObjectArrays(result)
+	}
 	if result.Type != TypeArray || field == "" {
 		return Result{Type: TypeUndefined}
 	}
@@ -4819,19 +7439,11 @@ func applySliceModifier(result Result, arg string) Result {
 		return Result{Type: TypeUndefined}
 	}
 
-	items := result.Array()
-	n := len(items)
-	if n == 0 {
-		return Result{Type: TypeArray, Raw: []byte("[]"), Modified: true}
-	}
-
-	start, end := parseSliceIndices(arg, n)
-
-	if start >= end || start >= n {
+	sliced := sliceResults(result.Array(), arg)
+	if len(sliced) == 0 {
 		return Result{Type: TypeArray, Raw: []byte("[]"), Modified: true}
 	}
 
-	sliced := items[start:end]
 	slicedResult := buildWildcardResult(sliced)
 	if slicedResult.Type == TypeUndefined {
 		return Result{Type: TypeArray, Raw: []byte("[]"), Modified: true}
@@ -4840,40 +7452,81 @@ func applySliceModifier(result Result, arg string) Result {
 	return slicedResult
 }
 
-// parseSliceIndices parses slice arguments and clamps to bounds
-func parseSliceIndices(arg string, n int) (int, int) {
-	// Parse start:end from arg
-	start, end := 0, n
-	if arg != "" {
-		parts := strings.Split(arg, ":")
-		if len(parts) >= 1 && parts[0] != "" {
-			if s, err := strconv.Atoi(parts[0]); err == nil {
-				start = s
-			}
+// sliceResults applies a start:end[:step] slice to items. Negative indices
+// count from the end and a negative step walks backwards, so ::-1 reverses.
+// A zero step selects nothing.
+func sliceResults(items []Result, arg string) []Result {
+	start, end, step := parseSliceIndices(arg, len(items))
+
+	var sliced []Result
+	switch {
+	case step > 0:
+		for i := start; i < end; i += step {
+			sliced = append(sliced, items[i])
 		}
-		if len(parts) >= 2 && parts[1] != "" {
-			if e, err := strconv.Atoi(parts[1]); err == nil {
-				end = e
-			}
+	case step < 0:
+		for i := start; i > end; i += step {
+			sliced = append(sliced, items[i])
 		}
 	}
+	return sliced
+}
 
-	// Handle negative indices
-	if start < 0 {
-		start = n + start
+// parseSliceIndices parses start:end[:step] slice arguments and clamps them
+// to the bounds of an array of length n. With a negative step, start
+// defaults to the last element and end to just before the first.
+func parseSliceIndices(arg string, n int) (start, end, step int) {
+	parts := strings.Split(arg, ":")
+	step = 1
+	if len(parts) >= 3 {
+		if v, ok := sliceBound(parts[2]); ok {
+			step = v
+		}
 	}
-	if end < 0 {
-		end = n + end
+	if step == 0 {
+		return 0, 0, 0
 	}
 
-	// Clamp to bounds
-	if start < 0 {
-		start = 0
+	lo, hi := 0, n
+	start, end = 0, n
+	if step < 0 {
+		lo, hi = -1, n-1
+		start, end = n-1, -1
+	}
+
+	if v, ok := sliceBound(parts[0]); ok {
+		start = clampSliceBound(v, n, lo, hi)
+	}
+	if len(parts) >= 2 {
+		if v, ok := sliceBound(parts[1]); ok {
+			end = clampSliceBound(v, n, lo, hi)
+		}
+	}
+	return start, end, step
+}
+
+// sliceBound parses one component of a slice; empty means "use the default"
+func sliceBound(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.Atoi(s)
+	return v, err == nil
+}
+
+// clampSliceBound resolves a negative index against n and clamps to [lo, hi]
+func clampSliceBound(v, n, lo, hi int) int {
+	if v < 0 {
+		v += n
 	}
-	if end > n {
-		end = n
+	if v < lo {
+		return lo
 	}
-	return start, end
+	if v > hi {
+		return hi
+	}
+	return v
 }
 
 // applyHasModifier checks if an object has a field or array has index
@@ -5015,58 +7668,77 @@ func applyEntriesToModifier(result Result) Result {
 	return Result{Type: TypeArray, Raw: buf.Bytes(), Modified: true}
 }
 
-// applyFromEntriesModifier converts array of {key, value} entries to object
-// Example: [{"key":"a","value":1}]|@fromentries returns {"a":1}
+// applyFromEntriesModifier converts an array of {key, value} entries or
+// [key, value] pairs to an object. For duplicate keys the last value wins.
+// Example: [{"key":"a","value":1},["b",2]]|@toObject returns {"a":1,"b":2}
 func applyFromEntriesModifier(result Result) Result {
 	if result.Type != TypeArray {
 		return Result{Type: TypeUndefined}
 	}
 
-	var buf bytes.Buffer
-	buf.WriteByte('{')
-	first := true
+	var keys []string
+	values := make(map[string][]byte)
 
 	result.ForEach(func(_, entry Result) bool {
-		if entry.Type != TypeObject {
+		keyResult, valueResult := entryKeyValue(entry)
+		if !keyResult.Exists() || !valueResult.Exists() {
 			return true
 		}
 
-		keyResult := Get(entry.Raw, "key")
-		valueResult := Get(entry.Raw, "value")
-
-		// Also support "k" and "v" or "name" and "value"
-		if !keyResult.Exists() {
-			keyResult = Get(entry.Raw, "k")
-		}
-		if !keyResult.Exists() {
-			keyResult = Get(entry.Raw, "name")
-		}
-		if !valueResult.Exists() {
-			valueResult = Get(entry.Raw, "v")
-		}
-
-		if !keyResult.Exists() || !valueResult.Exists() {
-			return true
+		keyStr := keyResult.String()
+		if _, dup := values[keyStr]; !dup {
+			keys = append(keys, keyStr)
 		}
+		values[keyStr] = valueResult.Raw
+		return true
+	})
 
-		if !first {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
 			buf.WriteByte(',')
 		}
-		first = false
-
 		// Ensure key is a quoted string
-		keyStr := keyResult.String()
 		buf.WriteByte('"')
-		buf.WriteString(escapeString(keyStr))
+		buf.WriteString(escapeString(key))
 		buf.WriteString(`":`)
-		buf.Write(valueResult.Raw)
-		return true
-	})
-
+		buf.Write(values[key])
+	}
 	buf.WriteByte('}')
 	return Result{Type: TypeObject, Raw: buf.Bytes(), Modified: true}
 }
 
+// entryKeyValue extracts the key and value of a single entry for
+// applyFromEntriesModifier
+func entryKeyValue(entry Result) (key, value Result) {
+	if entry.Type == TypeArray {
+		pair := entry.Array()
+		if len(pair) != 2 {
+			return Result{}, Result{}
+		}
+		return pair[0], pair[1]
+	}
+	if entry.Type != TypeObject {
+		return Result{}, Result{}
+	}
+
+	key = Get(entry.Raw, "key")
+	value = Get(entry.Raw, "value")
+
+	// Also support "k" and "v" or "name" and "value"
+	if !key.Exists() {
+		key = Get(entry.Raw, "k")
+	}
+	if !key.Exists() {
+		key = Get(entry.Raw, "name")
+	}
+	if !value.Exists() {
+		value = Get(entry.Raw, "v")
+	}
+	return key, value
+}
+
 // applyAnyModifier checks if any element in array is truthy
 // Example: [false,true,false]|@any returns true
 func applyAnyModifier(result Result) Result {
@@ -5144,11 +7816,21 @@ func numericValue(result Result) (float64, bool) {
 }
 
 func buildNumberResult(value float64) Result {
-	raw := strconv.FormatFloat(value, 'f', -1, 64)
+	var raw []byte
+	switch {
+	case math.IsNaN(value):
+		raw = []byte("NaN")
+	case math.IsInf(value, 1):
+		raw = []byte("Infinity")
+	case math.IsInf(value, -1):
+		raw = []byte("-Infinity")
+	default:
+		raw = strconv.AppendFloat(nil, value, 'f', -1, 64)
+	}
 	return Result{
 		Type:     TypeNumber,
 		Num:      value,
-		Raw:      []byte(raw),
+		Raw:      raw,
 		Modified: true,
 	}
 }
@@ -5161,11 +7843,6 @@ func skipWhitespaceSimple(data []byte, i int) int {
 	return i
 }
 
-// isComplexValueStart checks if a byte starts a complex JSON value
-func isComplexValueStart(b byte) bool {
-	return b == '{' || b == '[' || b == '"' || b == 't' || b == 'f' || b == 'n'
-}
-
 // scanNumber scans a number from data starting at position i
 // Returns the parsed number, new position, and success flag
 func scanNumber(data []byte, i int) (float64, int, bool) {
@@ -5201,8 +7878,8 @@ func scanArrayNumbersFast(data []byte) (sum float64, count int) {
 			sum += num
 			count++
 			i = newPos
-		} else if isComplexValueStart(data[i]) {
-			return 0, 0 // Complex value, bail
+		} else {
+			return 0, 0 // Complex or non-standard value, bail
 		}
 
 		// Skip whitespace and comma
@@ -5234,8 +7911,8 @@ func scanArrayMinMaxFast(data []byte, findMin bool) (value float64, found bool)
 			if !ok {
 				return 0, false
 			}
-		} else if isComplexValueStart(data[i]) {
-			return 0, false // Complex value, bail
+		} else {
+			return 0, false // Complex or non-standard value, bail
 		}
 
 		// Skip comma
@@ -5282,10 +7959,99 @@ func applyValidModifier(result Result) Result {
 		result.Modified = true
 		return result
 	}
-	return Result{Type: TypeUndefined}
+	return Result{Type: TypeUndefined}
+}
+
+// applyFlattenKeysModifier turns nested objects into one object whose keys
+// are dotted paths; dots and backslashes inside keys are escaped. Arrays and
+// empty objects are kept as values. Example: {"a":{"b":1}}|@flattenKeys
+// returns {"a.b":1}
+func applyFlattenKeysModifier(result Result) Result {
+	if result.Type != TypeObject {
+		return result
+	}
+	raw := appendFlattenedKeys(append(make([]byte, 0, len(result.Raw)), '{'), "", result, new(bool))
+	return Result{Type: TypeObject, Raw: append(raw, '}'), Modified: true}
+}
+
+// flattenKeyEscaper escapes the characters that would make a flattened key ambiguous
+var flattenKeyEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`)
+
+func appendFlattenedKeys(dst []byte, prefix string, obj Result, wrote *bool) []byte {
+	obj.ForEach(func(k, v Result) bool {
+		key := flattenKeyEscaper.Replace(k.Str)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if v.Type == TypeObject && !v.IsEmpty() {
+			dst = appendFlattenedKeys(dst, key, v, wrote)
+			return true
+		}
+		if *wrote {
+			dst = append(dst, ',')
+		}
+		*wrote = true
+		dst = append(dst, '"')
+		dst = append(dst, escapeString(key)...)
+		dst = append(dst, '"', ':')
+		dst = append(dst, bytes.TrimSpace(v.Raw)...)
+		return true
+	})
+	return dst
+}
+
+// applySortKeysModifier reorders object members by key, at every depth, and
+// compacts the output. Example: config.@sortKeys|@pretty
+func applySortKeysModifier(result Result) Result {
+	if result.Type != TypeObject && result.Type != TypeArray {
+		return result
+	}
+	raw := appendSortedKeys(make([]byte, 0, len(result.Raw)), result)
+	return Result{Type: result.Type, Raw: raw, Modified: true}
+}
+
+func appendSortedKeys(dst []byte, value Result) []byte {
+	switch value.Type {
+	case TypeObject:
+		type member struct{ key, value Result }
+		var members []member
+		value.ForEach(func(k, v Result) bool {
+			members = append(members, member{k, v})
+			return true
+		})
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].key.Str < members[j].key.Str
+		})
+
+		dst = append(dst, '{')
+		for i, m := range members {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, m.key.Raw...)
+			dst = append(dst, ':')
+			dst = appendSortedKeys(dst, m.value)
+		}
+		return append(dst, '}')
+	case TypeArray:
+		dst = append(dst, '[')
+		i := 0
+		value.ForEach(func(_, v Result) bool {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendSortedKeys(dst, v)
+			i++
+			return true
+		})
+		return append(dst, ']')
+	}
+	return append(dst, value.Raw...)
 }
 
-// applyPrettyModifier formats JSON with indentation (@pretty)
+// applyPrettyModifier formats JSON with indentation (@pretty). The result
+// keeps the type of the matched value, so user.@pretty is still an object
+// whose Raw is the indented text; scalars come back unchanged.
 func applyPrettyModifier(result Result, arg string) Result {
 	if len(result.Raw) == 0 {
 		return result
@@ -5322,7 +8088,8 @@ func applyPrettyModifier(result Result, arg string) Result {
 	}
 }
 
-// applyUglyModifier minifies JSON by removing whitespace (@ugly)
+// applyUglyModifier minifies JSON by removing whitespace (@ugly). Like
+// @pretty it keeps the type of the matched value.
 func applyUglyModifier(result Result) Result {
 	if len(result.Raw) == 0 {
 		return result
@@ -5900,6 +8667,9 @@ func findNullEnd(data []byte, start int) int {
 
 // findNumberEnd finds the end of a number value
 func findNumberEnd(data []byte, start int) int {
+	if n := nonFiniteLen(data, start); n > 0 {
+		return start + n
+	}
 	// Number - scan until non-number character
 	if isNumberStart(data[start]) {
 		for i := start + 1; i < len(data); i++ {
@@ -6120,6 +8890,9 @@ func parseNumber(data []byte, start int) Result {
 	if start >= len(data) {
 		return Result{Type: TypeUndefined}
 	}
+	if n := nonFiniteLen(data, start); n > 0 {
+		return parseNonFinite(data[start:start+n], start)
+	}
 
 	// Find the end of the number
 	end := start
@@ -6164,6 +8937,44 @@ func parseNumber(data []byte, start int) Result {
 	}
 }
 
+// nonFiniteLen returns the length of a NaN, Infinity or -Infinity token at
+// data[start:], or 0. Such tokens are not JSON, but some producers emit them;
+// the scanners step over them like numbers so the rest of the document stays
+// reachable, and only GetWithOptions with AllowNonFiniteNumbers returns them.
+func nonFiniteLen(data []byte, start int) int {
+	rest := data[start:]
+	switch {
+	case len(rest) >= 3 && rest[0] == 'N' && string(rest[:3]) == "NaN":
+		return 3
+	case len(rest) >= 8 && rest[0] == 'I' && string(rest[:8]) == "Infinity":
+		return 8
+	case len(rest) >= 9 && rest[0] == '-' && rest[1] == 'I' && string(rest[1:9]) == "Infinity":
+		return 9
+	}
+	return 0
+}
+
+// parseNonFinite builds the number Result of a token found by nonFiniteLen
+func parseNonFinite(raw []byte, start int) Result {
+	num := math.NaN()
+	switch raw[0] {
+	case 'I':
+		num = math.Inf(1)
+	case '-':
+		num = math.Inf(-1)
+	}
+	return Result{Type: TypeNumber, Num: num, Raw: raw, Index: start}
+}
+
+// rejectNonFinite turns a result that is one of the NaN or Infinity tokens
+// into a missing value, as Get does not accept them
+func rejectNonFinite(r Result) Result {
+	if r.Type == TypeNumber && len(r.Raw) > 0 && nonFiniteLen(r.Raw, 0) == len(r.Raw) {
+		return Result{Type: TypeUndefined}
+	}
+	return r
+}
+
 // parseAny parses any JSON value
 func parseAny(data []byte) Result {
 	// Skip leading whitespace
@@ -6201,7 +9012,7 @@ func parseAny(data []byte) Result {
 			return result
 		}
 	default:
-		if isNumericChar(data[start]) || data[start] == '+' {
+		if isNumericChar(data[start]) || data[start] == '+' || nonFiniteLen(data, start) > 0 {
 			return parseNumber(data, start)
 		}
 	}
@@ -6213,7 +9024,8 @@ func parseAny(data []byte) Result {
 // RESULT METHODS
 //------------------------------------------------------------------------------
 
-// String returns the result as a string
+// String returns the result as a string. For a string value this is the
+// unescaped content without quotes; Raw holds the quoted source token.
 func (r Result) String() string {
 	switch r.Type {
 	case TypeString:
@@ -6231,6 +9043,80 @@ func (r Result) String() string {
 	}
 }
 
+// StringN is like String but returns at most the first n runes, stopping as
+// soon as it has them. A string is sliced from its unescaped value without
+// copying; an array or object copies only that prefix of its raw JSON.
+// n <= 0 returns "".
+func (r Result) StringN(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if r.Type == TypeArray || r.Type == TypeObject {
+		return string(r.Raw[:runePrefixLen(r.Raw, n)])
+	}
+	s := r.String()
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
+
+// runePrefixLen returns the byte length of the first n runes of b
+func runePrefixLen(b []byte, n int) int {
+	i := 0
+	for ; n > 0 && i < len(b); n-- {
+		_, size := utf8.DecodeRune(b[i:])
+		i += size
+	}
+	return i
+}
+
+// GoString implements fmt.GoStringer so that %#v prints a compact summary,
+// e.g. Result{Type:string, Raw:"value", Exists:true}. Raw is shown verbatim.
+func (r Result) GoString() string {
+	return "Result{Type:" + typeName(r.Type) + ", Raw:" + string(r.Raw) +
+		", Exists:" + strconv.FormatBool(r.Exists()) + "}"
+}
+
+// Summary returns the result as minified single-line JSON for logging. If
+// that is longer than maxLen bytes it is cut at a rune boundary and ends in
+// "...", keeping the whole summary within maxLen. maxLen <= 0 means no limit.
+func (r Result) Summary(maxLen int) string {
+	if !r.Exists() {
+		return ""
+	}
+	raw := r.Raw
+	if min, err := Ugly(raw); err == nil {
+		raw = min
+	}
+	if maxLen <= 0 || len(raw) <= maxLen {
+		return string(raw)
+	}
+
+	const ellipsis = "..."
+	if maxLen <= len(ellipsis) {
+		return ellipsis[:maxLen]
+	}
+	cut := maxLen - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(raw[cut]) {
+		cut--
+	}
+	return string(raw[:cut]) + ellipsis
+}
+
+// Reader returns an io.Reader over the raw JSON of the result without
+// copying it. A non-existent result reads as null.
+func (r Result) Reader() io.Reader {
+	if !r.Exists() {
+		return strings.NewReader("null")
+	}
+	return bytes.NewReader(r.Raw)
+}
+
 // Int returns the result as an int64
 func (r Result) Int() int64 {
 	switch r.Type {
@@ -6249,7 +9135,8 @@ func (r Result) Int() int64 {
 	}
 }
 
-// Uint returns the result as a uint64
+// Uint returns the result as a uint64. Negative numbers clamp to 0 and
+// fractions are truncated toward zero.
 func (r Result) Uint() uint64 {
 	switch r.Type {
 	case TypeNumber:
@@ -6258,6 +9145,12 @@ func (r Result) Uint() uint64 {
 				return n
 			}
 		}
+		if r.Num <= 0 {
+			return 0
+		}
+		if r.Num >= math.MaxUint64 {
+			return math.MaxUint64
+		}
 		return uint64(r.Num)
 	case TypeString:
 		n, _ := strconv.ParseUint(r.Str, 10, 64)
@@ -6290,6 +9183,16 @@ func (r Result) Float() float64 {
 	}
 }
 
+// NumberString returns the number exactly as written in the source, so
+// 1.2300 keeps its trailing zeros and large integers lose no precision.
+// It returns "" if the result is not a number.
+func (r Result) NumberString() string {
+	if r.Type != TypeNumber {
+		return ""
+	}
+	return string(bytes.TrimSpace(r.Raw))
+}
+
 // Bool returns the result as a boolean
 func (r Result) Bool() bool {
 	switch r.Type {
@@ -6326,6 +9229,48 @@ func (r Result) IsObject() bool {
 	return r.Type == TypeObject
 }
 
+// IsInteger reports whether r is a number with an integral value, judged
+// exactly from the raw token rather than the float64. So 1, -0, 1e2 and
+// 1.50e2 are integers, as are 1.0 and 2.000 whose fractions are all zeros,
+// while 1.5 and 1e-1 are not. Non-numbers are never integers.
+func (r Result) IsInteger() bool {
+	if r.Type != TypeNumber {
+		return false
+	}
+	raw := bytes.TrimPrefix(r.Raw, []byte{'-'})
+	mantissa, exp := raw, 0
+	if i := bytes.IndexAny(raw, "eE"); i >= 0 {
+		e, err := strconv.Atoi(string(raw[i+1:]))
+		if err != nil {
+			return false
+		}
+		mantissa, exp = raw[:i], e
+	}
+	intPart, frac, _ := bytes.Cut(mantissa, []byte{'.'})
+	digits := append(append([]byte{}, intPart...), frac...)
+	exp -= len(frac)
+
+	// Trailing zeros move the decimal point right; the value is integral
+	// once no significant digit is left after it.
+	significant := bytes.TrimRight(digits, "0")
+	return len(significant) == 0 || exp+len(digits)-len(significant) >= 0
+}
+
+// IsEmpty reports whether r is undefined, null, an empty string, or an
+// object or array with no members.
+func (r Result) IsEmpty() bool {
+	switch r.Type {
+	case TypeUndefined, TypeNull:
+		return true
+	case TypeString:
+		return r.Str == ""
+	case TypeObject, TypeArray:
+		raw := bytes.TrimSpace(r.Raw)
+		return len(raw) >= 2 && len(bytes.TrimSpace(raw[1:len(raw)-1])) == 0
+	}
+	return false
+}
+
 // Array returns the result as a slice of results
 func (r Result) Array() []Result {
 	if r.Type != TypeArray {
@@ -6339,6 +9284,49 @@ func (r Result) Array() []Result {
 	return results
 }
 
+// IntSlice returns each element of an array result converted with Int, so
+// elements that are not numeric become 0. It returns nil for non-arrays.
+func (r Result) IntSlice() []int64 {
+	if r.Type != TypeArray {
+		return nil
+	}
+	values := []int64{}
+	r.ForEach(func(_, value Result) bool {
+		values = append(values, value.Int())
+		return true
+	})
+	return values
+}
+
+// FloatSlice returns each element of an array result converted with Float.
+// It returns nil for non-arrays.
+func (r Result) FloatSlice() []float64 {
+	if r.Type != TypeArray {
+		return nil
+	}
+	values := []float64{}
+	r.ForEach(func(_, value Result) bool {
+		values = append(values, value.Float())
+		return true
+	})
+	return values
+}
+
+// StringSlice returns each element of an array result converted with
+// String, so nested objects and arrays come back as raw JSON. It returns
+// nil for non-arrays.
+func (r Result) StringSlice() []string {
+	if r.Type != TypeArray {
+		return nil
+	}
+	values := []string{}
+	r.ForEach(func(_, value Result) bool {
+		values = append(values, value.String())
+		return true
+	})
+	return values
+}
+
 // Map returns the result as a map
 func (r Result) Map() map[string]Result {
 	if r.Type != TypeObject {
@@ -6379,6 +9367,100 @@ func (r Result) ForEach(iterator func(key, value Result) bool) {
 	}
 }
 
+// ForEachIndex calls fn with the position and value of each element of an
+// array result, stopping early if fn returns false. It does nothing for
+// other types. ForEach passes the same position as a number key.
+func (r Result) ForEachIndex(fn func(i int, value Result) bool) {
+	if r.Type != TypeArray {
+		return
+	}
+	i := 0
+	r.ForEach(func(_, value Result) bool {
+		ok := fn(i, value)
+		i++
+		return ok
+	})
+}
+
+// ForEachReuse is like ForEach but passes the same two Result values on
+// every call, so iterating does not allocate per element. The pointers, and
+// the key string of an array index, are only valid until fn returns; copy
+// what you need to keep, e.g. v := *value.
+func (r Result) ForEachReuse(fn func(key, value *Result) bool) {
+	if r.Type != TypeArray && r.Type != TypeObject {
+		return
+	}
+
+	key, value := new(Result), new(Result)
+	if r.Type == TypeObject {
+		r.ForEach(func(k, v Result) bool {
+			*key, *value = k, v
+			return fn(key, value)
+		})
+		return
+	}
+
+	start := bytes.IndexByte(r.Raw, '[')
+	if start < 0 {
+		return
+	}
+	raw := r.Raw
+	keyBuf := make([]byte, 0, 20)
+	index := 0
+	for pos := start + 1; pos < len(raw); index++ {
+		for ; pos < len(raw) && raw[pos] <= ' '; pos++ {
+		}
+		if pos >= len(raw) || raw[pos] == ']' {
+			return
+		}
+		valueEnd := findValueEnd(raw, pos)
+		if valueEnd == -1 {
+			return
+		}
+
+		keyBuf = strconv.AppendInt(keyBuf[:0], int64(index), 10)
+		*key = Result{Type: TypeNumber, Num: float64(index), Str: bytesToString(keyBuf)}
+		*value = parseAny(raw[pos:valueEnd])
+		value.Raw = raw[pos:valueEnd]
+		if !fn(key, value) {
+			return
+		}
+
+		pos = valueEnd
+		for ; pos < len(raw) && (raw[pos] <= ' ' || raw[pos] == ','); pos++ {
+			if raw[pos] == ',' {
+				pos++
+				break
+			}
+		}
+	}
+}
+
+// Channel sends each element of an array, or each value of an object, on a
+// channel with the given buffer size and closes it when done. Other types
+// yield a closed, empty channel. The results reference r's bytes, which must
+// remain valid and unmodified until the channel is drained. The consumer must
+// read until the channel is closed, or the sending goroutine will block.
+func (r Result) Channel(bufSize int) <-chan Result {
+	if bufSize < 0 {
+		bufSize = 0
+	}
+	ch := make(chan Result, bufSize)
+	if r.Type != TypeArray && r.Type != TypeObject {
+		close(ch)
+		return ch
+	}
+
+	go func() {
+		defer close(ch)
+		r.ForEach(func(_, value Result) bool {
+			ch <- value
+			return true
+		})
+	}()
+	return ch
+}
+
 // forEachArrayRaw iterates over array elements starting at pos
 func forEachArrayRaw(raw []byte, pos int, iterator func(key, value Result) bool) {
 	index := 0
@@ -6510,32 +9592,163 @@ func (r Result) Get(path string) Result {
 	return Get(r.Raw, path)
 }
 
-// Time parses the result as a time.Time
-func (r Result) Time() (time.Time, error) {
-	if r.Type != TypeString {
-		return time.Time{}, ErrTypeConversion
+// Field returns the member named name. The name is taken literally, so
+// dots and wildcards need no escaping. Intended for text/template, as in
+// {{(.Field "user").Field "name"}}.
+func (r Result) Field(name string) Result {
+	if r.Type != TypeObject {
+		return Result{Type: TypeUndefined}
+	}
+	if name == "" {
+		return Result{Type: TypeUndefined}
+	}
+	if isAllDigitsGet(name) {
+		return r.Get(":" + name)
+	}
+	return r.Get(EscapePathSegment(name))
+}
+
+// Item returns the array element at index i, for use from text/template
+func (r Result) Item(i int) Result {
+	if r.Type != TypeArray || i < 0 {
+		return Result{Type: TypeUndefined}
+	}
+	return r.Get(strconv.Itoa(i))
+}
+
+// Child returns the member named keyOrIndex if r is an object, or the element
+// at that index if r is an array, so generic walkers can descend either way
+// with one call. The key is taken literally; negative indexes count from the
+// end of the array.
+func (r Result) Child(keyOrIndex string) Result {
+	var child Result
+	switch r.Type {
+	case TypeObject:
+		child, _ = processKeyToken(r, pathToken{kind: tokenKey, str: keyOrIndex})
+	case TypeArray:
+		i, err := strconv.Atoi(keyOrIndex)
+		if err != nil {
+			return Result{Type: TypeUndefined}
+		}
+		child, _ = processIndexToken(r, pathToken{kind: tokenIndex, num: i})
+	default:
+		return Result{Type: TypeUndefined}
+	}
+	return child
+}
+
+// Matches evaluates a query predicate such as "#(age>30)" against r, as if r
+// were the element being filtered. The "#(" and ")" wrapper is optional.
+// It returns ErrInvalidQuery for a malformed filter.
+func (r Result) Matches(filter string) (bool, error) {
+	cond := strings.TrimSpace(filter)
+	if strings.HasPrefix(cond, "#(") {
+		if !strings.HasSuffix(cond, ")") {
+			return false, ErrInvalidQuery
+		}
+		cond = cond[2 : len(cond)-1]
+	}
+	if strings.TrimSpace(cond) == "" || !balancedParens(cond) {
+		return false, ErrInvalidQuery
+	}
+
+	if op, idx := findQueryOperator(cond); idx >= 0 && strings.TrimSpace(cond[idx+len(op):]) == "" {
+		return false, ErrInvalidQuery // operator without a right-hand side
+	}
+	if !r.Exists() {
+		return false, nil
+	}
+	return matchesQueryCondition(r, parseQueryCondition(cond)), nil
+}
+
+// balancedParens reports whether parentheses outside string literals balance
+func balancedParens(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
 	}
+	return depth == 0 && quote == 0
+}
 
-	// Try standard formats
-	for _, layout := range []string{
-		time.RFC3339,
-		time.RFC3339Nano,
-		time.RFC1123,
-		time.RFC1123Z,
-		time.RFC822,
-		time.RFC822Z,
-		"2006-01-02T15:04:05",
-		"2006-01-02 15:04:05",
-		"2006-01-02",
-	} {
-		if t, err := time.Parse(layout, r.Str); err == nil {
-			return t, nil
+// Document returns a copy of Raw that can be queried with Get as an
+// independent document. Raw itself usually aliases the original input and
+// is only valid for as long as that input is left unmodified.
+func (r Result) Document() []byte {
+	if len(r.Raw) == 0 {
+		return nil
+	}
+	doc := make([]byte, len(r.Raw))
+	copy(doc, r.Raw)
+	return doc
+}
+
+// timeLayouts are the layouts Time tries on strings, in order
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC822,
+	time.RFC822Z,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Time parses the result as a time.Time. A number is taken as seconds since
+// the Unix epoch, fractions included, and returned in UTC. A string is tried
+// against RFC3339, RFC3339Nano, RFC1123, RFC1123Z, RFC822 and RFC822Z, then
+// "2006-01-02T15:04:05", "2006-01-02 15:04:05" and "2006-01-02", and the
+// first layout that parses wins. Anything else returns ErrTypeConversion.
+func (r Result) Time() (time.Time, error) {
+	switch r.Type {
+	case TypeNumber:
+		if n, err := strconv.ParseInt(string(r.Raw), 10, 64); err == nil {
+			return time.Unix(n, 0).UTC(), nil
+		}
+		sec, frac := math.Modf(r.Num)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	case TypeString:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, r.Str); err == nil {
+				return t, nil
+			}
 		}
 	}
 
 	return time.Time{}, ErrTypeConversion
 }
 
+// TimeWithFormat parses a string result with the given Go time layout. It
+// returns ErrTypeConversion for non-strings, or wrapping the parse error.
+func (r Result) TimeWithFormat(layout string) (time.Time, error) {
+	if r.Type != TypeString {
+		return time.Time{}, ErrTypeConversion
+	}
+	t, err := time.Parse(layout, r.Str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrTypeConversion, err)
+	}
+	return t, nil
+}
+
 // Value returns the result as a native Go type (interface{}).
 // Returns:
 //   - nil for TypeNull or non-existent values
@@ -6555,24 +9768,111 @@ func (r Result) Value() interface{} {
 	case TypeString:
 		return r.Str
 	case TypeArray:
-		arr := r.Array()
-		result := make([]interface{}, len(arr))
-		for i, v := range arr {
-			result[i] = v.Value()
-		}
+		// Decode straight from ForEach to skip the intermediate []Result
+		result := []interface{}{}
+		r.ForEach(func(_, v Result) bool {
+			result = append(result, v.Value())
+			return true
+		})
 		return result
 	case TypeObject:
-		m := r.Map()
-		result := make(map[string]interface{}, len(m))
-		for k, v := range m {
-			result[k] = v.Value()
-		}
+		result := make(map[string]interface{})
+		r.ForEach(func(k, v Result) bool {
+			result[k.Str] = v.Value()
+			return true
+		})
 		return result
 	default:
 		return nil
 	}
 }
 
+// EqualValue reports whether r holds the same JSON value as v. v is
+// normalized through encoding/json first, so an int matches a JSON number
+// and a []int matches an array of numbers. An undefined result never matches.
+func (r Result) EqualValue(v interface{}) bool {
+	if !r.Exists() {
+		return false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	var want interface{}
+	if err := json.Unmarshal(data, &want); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(r.Value(), want)
+}
+
+// Hash returns a hash of the value that ignores formatting, the order of
+// object members and the spelling of numbers, so {"a":1,"b":2.0} and
+// {"b":2,"a":1} hash equal. An undefined result hashes to 0.
+func (r Result) Hash() uint64 {
+	if !r.Exists() {
+		return 0
+	}
+	return hashValue(fnvOffset64, r)
+}
+
+// hashValue folds a type tag and the canonical form of value into h
+func hashValue(h uint64, value Result) uint64 {
+	switch value.Type {
+	case TypeNull:
+		return fnv1a(h, "n")
+	case TypeBoolean:
+		if value.Boolean {
+			return fnv1a(h, "t")
+		}
+		return fnv1a(h, "f")
+	case TypeNumber:
+		h = fnv1a(h, "d")
+		return fnv1a(h, strconv.FormatFloat(value.Num, 'g', -1, 64))
+	case TypeString:
+		h = fnv1a(h, "s"+strconv.Itoa(len(value.Str))+":")
+		return fnv1a(h, value.Str)
+	case TypeArray:
+		h = fnv1a(h, "[")
+		value.ForEach(func(_, v Result) bool {
+			h = hashValue(h, v)
+			return true
+		})
+		return fnv1a(h, "]")
+	case TypeObject:
+		// Hash members on their own and sort them so order does not matter
+		var members []uint64
+		value.ForEach(func(k, v Result) bool {
+			m := fnv1a(fnvOffset64, strconv.Itoa(len(k.Str))+":"+k.Str)
+			members = append(members, hashValue(m, v))
+			return true
+		})
+		sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+		h = fnv1a(h, "{")
+		for _, m := range members {
+			for i := 0; i < 8; i++ {
+				h ^= (m >> (8 * i)) & 0xff
+				h *= fnvPrime64
+			}
+		}
+		return fnv1a(h, "}")
+	}
+	return h
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// fnv1a continues a 64-bit FNV-1a hash h over s
+func fnv1a(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
 // Less compares two Result values and returns true if r is less than token.
 // The comparison rules are:
 //   - Null < Boolean < Number < String < Array/Object
@@ -2,10 +2,19 @@ package nqjson
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"text/template"
+	"time"
+	"unicode/utf16"
 )
 
 // TestGet_BasicOperations tests basic GET functionality using table-driven tests
@@ -148,8 +157,8 @@ func TestGet_ArrayOperations(t *testing.T) {
 			name:   "array_negative_index",
 			json:   []byte(`{"items":["apple","banana","cherry"]}`),
 			path:   "items.-1",
-			want:   nil,
-			exists: false,
+			want:   "cherry",
+			exists: true,
 		},
 		{
 			name:   "nested_array_access",
@@ -1152,9 +1161,9 @@ func TestComplexPathOperations(t *testing.T) {
 		{"filter_active_users", "users[?(@.active==true)].name", true},
 		{"filter_by_age", "users[?(@.age>30)].name", true},
 		{"recursive_search_name", "..name", false},
-		{"modifier_length_invalid_syntax", "users.@length", false},
+		{"modifier_length_dot_syntax", "users.@length", true},
 		{"array_slice", "users[0:2].name", false},
-		{"array_negative_index", "users[-1].name", false},
+		{"array_negative_index", "users[-1].name", true},
 		{"invalid_modifier", "users.@invalid", false},
 		{"malformed_filter", "users[?(@.age)", false},
 	}
@@ -1232,9 +1241,9 @@ func TestArrayElementAccess(t *testing.T) {
 		{"last_element", "numbers.7", true},
 		{"out_of_bounds", "numbers.10", false},
 
-		// Negative indexing - these may not be supported
-		{"negative_last", "numbers.-1", false},
-		{"negative_first", "numbers.-8", false},
+		// Negative indexing counts from the end
+		{"negative_last", "numbers.-1", true},
+		{"negative_first", "numbers.-8", true},
 		{"negative_out_of_bounds", "numbers.-10", false},
 
 		// Nested array access
@@ -4290,13 +4299,13 @@ func TestEdgeCasesAndMissingCoverage(t *testing.T) {
 				name:        "date only",
 				json:        []byte(`{"date": "2023-10-17"}`),
 				path:        "date",
-				expectValid: false, // May not parse as time
+				expectValid: true,
 			},
 			{
 				name:        "epoch number",
 				json:        []byte(`{"epoch": 1697539800}`),
 				path:        "epoch",
-				expectValid: false, // Numbers may not parse as time
+				expectValid: true, // seconds since the Unix epoch
 			},
 			{
 				name:        "invalid time string",
@@ -7527,14 +7536,12 @@ func TestEdgeCases_ModifierErrorHandling(t *testing.T) {
 		json string
 		path string
 	}{
-		// Test @keys on non-object (should return undefined)
-		{"keys_on_array", `{"arr":[1,2,3]}`, "arr.@keys"},
+		// Test @keys on non-collection (should return undefined)
 		{"keys_on_string", `{"str":"hello"}`, "str.@keys"},
 		{"keys_on_number", `{"num":123}`, "num.@keys"},
 		{"keys_on_null", `{"val":null}`, "val.@keys"},
 
-		// Test @values on non-object (should return undefined)
-		{"values_on_array", `{"arr":[1,2,3]}`, "arr.@values"},
+		// Test @values on non-collection (should return undefined)
 		{"values_on_string", `{"str":"hello"}`, "str.@values"},
 		{"values_on_number", `{"num":123}`, "num.@values"},
 
@@ -8000,7 +8007,7 @@ func TestParseFunctions(t *testing.T) {
 			name:      "parseStringValue_with_escapes",
 			json:      `{"message": "Hello \"World\""}`,
 			path:      "message",
-			expectStr: `Hello \"World\"`,
+			expectStr: `Hello "World"`,
 		},
 		{
 			name:       "parseTrueValue_coverage",
@@ -9108,6 +9115,86 @@ func TestFormat_Pretty_CustomIndentation(t *testing.T) {
 	}
 }
 
+func TestResultPrettyColor(t *testing.T) {
+	json := []byte(`{"name":"a:b","n":-1.5e3,"ok":true,"none":null,"list":[1,"x"]}`)
+	r := Parse(json)
+	plain, err := Pretty(json)
+	if err != nil {
+		t.Fatalf("Pretty failed: %v", err)
+	}
+
+	if got := r.PrettyColor(&ColorOptions{Disabled: true}); string(got) != string(plain) {
+		t.Errorf("PrettyColor(disabled) = %s, want %s", got, plain)
+	}
+
+	colored := string(r.PrettyColor(nil))
+	c := DefaultColorOptions
+	for _, want := range []string{
+		c.Key + `"name"` + c.Reset + ": " + c.String + `"a:b"` + c.Reset,
+		c.Key + `"n"` + c.Reset + ": " + c.Number + `-1.5e3` + c.Reset,
+		c.Literal + "true" + c.Reset,
+		c.Literal + "null" + c.Reset,
+		c.Number + "1" + c.Reset,
+		c.String + `"x"` + c.Reset,
+	} {
+		if !strings.Contains(colored, want) {
+			t.Errorf("PrettyColor(nil) = %q, missing %q", colored, want)
+		}
+	}
+
+	stripped := strings.NewReplacer(c.Key, "", c.String, "", c.Number, "", c.Literal, "", c.Reset, "").Replace(colored)
+	if stripped != string(plain) {
+		t.Errorf("PrettyColor without escapes = %s, want %s", stripped, plain)
+	}
+
+	// Empty sequences leave those tokens uncolored
+	keysOnly := string(r.PrettyColor(&ColorOptions{Key: "<", Reset: ">"}))
+	if !strings.Contains(keysOnly, `<"name">: "a:b"`) {
+		t.Errorf("PrettyColor(keys only) = %s", keysOnly)
+	}
+
+	if Get(json, "missing").PrettyColor(nil) != nil {
+		t.Error("expected nil for a missing value")
+	}
+	if got := Get(json, "name").PrettyColor(nil); string(got) != c.String+`"a:b"`+c.Reset {
+		t.Errorf("PrettyColor(string) = %q", got)
+	}
+}
+
+func TestFormat_TrailingNewline(t *testing.T) {
+	input := []byte(`{"a": [1, 2]}`)
+	tests := []struct {
+		name   string
+		format func([]byte, *FormatOptions) ([]byte, error)
+		opts   *FormatOptions
+		want   string
+	}{
+		{"Pretty default", PrettyWithOptions, &FormatOptions{Indent: "  "}, "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"Pretty newline", PrettyWithOptions, &FormatOptions{Indent: "  ", TrailingNewline: true}, "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n"},
+		{"Pretty nil options", PrettyWithOptions, nil, "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"Pretty empty indent newline", PrettyWithOptions, &FormatOptions{TrailingNewline: true}, "{\"a\":[1,2]}\n"},
+		{"Ugly default", UglifyWithOptions, &FormatOptions{}, `{"a":[1,2]}`},
+		{"Ugly newline", UglifyWithOptions, &FormatOptions{TrailingNewline: true}, "{\"a\":[1,2]}\n"},
+		{"Ugly nil options", UglifyWithOptions, nil, `{"a":[1,2]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format(input, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Empty input stays empty
+	if got, _ := UglifyWithOptions(nil, &FormatOptions{TrailingNewline: true}); len(got) != 0 {
+		t.Errorf("empty input = %q, want empty", got)
+	}
+}
+
 func TestFormat_Pretty_ComplexStructures(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -9269,6 +9356,9 @@ func TestFormat_Valid_InvalidJSON(t *testing.T) {
 		{name: "Trailing Comma Object", input: []byte(`{"name":"John",}`), want: false},
 		{name: "Trailing Comma Array", input: []byte(`[1,2,3,]`), want: false},
 		{name: "Invalid Literal", input: []byte(`{"value":truee}`), want: false},
+		{name: "NaN Literal", input: []byte(`{"value":NaN}`), want: false},
+		{name: "Trailing Content", input: []byte(`{"a":1}{"b":2}`), want: false},
+		{name: "Leading Zero", input: []byte(`[01]`), want: false},
 	}
 
 	for _, tt := range tests {
@@ -9281,6 +9371,61 @@ func TestFormat_Valid_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestValidWithError(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		offset  int
+		message string
+	}{
+		{name: "Empty Input", input: ``, offset: 0, message: "unexpected end of input"},
+		{name: "Unclosed Object", input: `{"name":"John"`, offset: 14, message: "unterminated object"},
+		{name: "Unclosed Array", input: `[1,2,3`, offset: 6, message: "unterminated array"},
+		{name: "Unterminated String", input: `{"name":"John`, offset: 8, message: "unterminated string"},
+		{name: "Invalid Number", input: `{"age":3.}`, offset: 7, message: "invalid number"},
+		{name: "Missing Colon", input: `{"name""John"}`, offset: 7, message: "expected ':' after object key"},
+		{name: "Trailing Comma Object", input: `{"name":"John",}`, offset: 15, message: "expected object key"},
+		{name: "Trailing Comma Array", input: `[1,2,3,]`, offset: 7, message: "unexpected character ']'"},
+		{name: "Invalid Literal", input: `{"value":truee}`, offset: 13, message: "expected ',' or '}' in object"},
+		{name: "NaN Literal", input: `{"value":NaN}`, offset: 9, message: "unexpected character 'N'"},
+		{name: "Trailing Content", input: `{"a":1}{"b":2}`, offset: 7, message: "unexpected data after top-level value"},
+		{name: "Leading Zero", input: `[01]`, offset: 2, message: "expected ',' or ']' in array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidWithError([]byte(tt.input))
+			fe, ok := err.(*FormatError)
+			if !ok {
+				t.Fatalf("ValidWithError(%q) = %v, want *FormatError", tt.input, err)
+			}
+			if fe.Offset != tt.offset || fe.Message != tt.message {
+				t.Errorf("ValidWithError(%q) = {%q, %d}, want {%q, %d}", tt.input, fe.Message, fe.Offset, tt.message, tt.offset)
+			}
+		})
+	}
+
+	for _, in := range []string{`{"a":[1,2,{"b":null}]}`, ` true `, `"x"`} {
+		if err := ValidWithError([]byte(in)); err != nil {
+			t.Errorf("ValidWithError(%q) = %v, want nil", in, err)
+		}
+	}
+
+	// Nesting is capped instead of growing the stack without bound
+	atLimit := strings.Repeat("[", maxScanDepth) + strings.Repeat("]", maxScanDepth)
+	if err := ValidWithError([]byte(atLimit)); err != nil {
+		t.Errorf("ValidWithError at the depth limit = %v, want nil", err)
+	}
+	deep := []byte(strings.Repeat(`[{"a":`, 2_000_000))
+	fe, ok := ValidWithError(deep).(*FormatError)
+	if !ok || fe.Message != "exceeded max nesting depth" || fe.Offset != 3*maxScanDepth {
+		t.Errorf("ValidWithError on deeply nested input = %v", fe)
+	}
+	if Valid(deep) {
+		t.Error("Valid accepted deeply nested input")
+	}
+}
+
 func TestFormat_EdgeCases(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -9471,6 +9616,51 @@ func TestColonPrefix_Get(t *testing.T) {
 	}
 }
 
+func TestEscapedWildcardKeys_Get(t *testing.T) {
+	json := []byte(`{
+		"stats": {"*": 1, "#": 2, "a*": 3, "ab": 4, "a?": 5, "#(x)": 6, "total": 7},
+		"*": "star",
+		"#": "hash"
+	}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`stats.\*`, `1`},
+		{`stats.\#`, `2`},
+		{`stats.a\*`, `3`},
+		{`stats.a\?`, `5`},
+		{`stats.\#(x)`, `6`},
+		{`\*`, `"star"`},
+		{`\#`, `"hash"`},
+		{`stats.total`, `7`},
+		// Unescaped operators keep their meaning
+		{`stats.#`, `7`},
+		{`stats.a*`, `[3,4,5]`},
+		{`stats.a\**`, `3`},
+		{`stats.*|@count`, `7`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Get(json, tt.path).Raw; string(got) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if !matchKeyPattern("a*b", `a\*b`) || matchKeyPattern("axb", `a\*b`) {
+		t.Error(`matchKeyPattern should treat \* as a literal asterisk`)
+	}
+
+	// The % filter operator keeps plain glob semantics, backslashes included
+	paths := []byte(`{"p":[{"path":"C:\\x"},{"path":"D:\\y"}]}`)
+	if got := Get(paths, `p.#(path%"C:\\*")#.path`).Raw; string(got) != `["C:\\x"]` {
+		t.Errorf(`%% filter with a backslash = %s, want ["C:\\x"]`, got)
+	}
+}
+
 func TestCombinedEscapeAndColon_Get(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -9675,6 +9865,26 @@ func TestPathEscapeHelpers(t *testing.T) {
 			t.Fatalf("JSON does not contain Unicode key %q as-is. Got: %s", unicodeKey, jsonStr)
 		}
 	})
+
+	t.Run("round_trip_split", func(t *testing.T) {
+		keys := []string{"user.name", "a:b", `back\slash`, `mix.of:all\\`, "plain", "v1.2.3"}
+		path := BuildEscapedPath(keys...)
+
+		parts := splitPath(path)
+		if len(parts) != len(keys) {
+			t.Fatalf("splitPath(%q) returned %d parts, want %d", path, len(parts), len(keys))
+		}
+		for i, part := range parts {
+			if got := unescapePath(part); got != keys[i] {
+				t.Errorf("unescapePath(%q) = %q, want %q", part, got, keys[i])
+			}
+		}
+
+		data := []byte(`{"user.name":{"a:b":{"back\\slash":1}}}`)
+		if got := Get(data, BuildEscapedPath(keys[:3]...)).Int(); got != 1 {
+			t.Errorf("Get with escaped keys = %d, want 1", got)
+		}
+	})
 }
 
 // =============================================================================
@@ -9827,6 +10037,39 @@ func TestPathSyntax_ModifierValues(t *testing.T) {
 	}
 }
 
+func TestPathSyntax_ModifierKeysValuesOnArrays(t *testing.T) {
+	json := []byte(`{"arr":["a",{"b":1},3],"empty":[],"obj":{"x":1}}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"arr|@keys", `[0,1,2]`},
+		{"arr|@values", `["a",{"b":1},3]`},
+		{"arr.@keys", `[0,1,2]`},
+		{"arr.@values", `["a",{"b":1},3]`},
+		{"empty|@keys", `[]`},
+		{"empty|@values", `[]`},
+		{"arr|@keys|@count", `3`},
+		{"arr|@values|@count", `3`},
+		{"obj|@keys", `["x"]`},
+		{"obj|@values", `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+
+	if Get(json, "obj.x|@keys").Exists() {
+		t.Error("expected @keys on a number to be undefined")
+	}
+}
+
 func TestPathSyntax_ModifierFlatten(t *testing.T) {
 	json := `{"a":[[1,2],[3,4]]}`
 	result := Get([]byte(json), "a|@flatten")
@@ -9843,6 +10086,31 @@ func TestPathSyntax_ModifierThis(t *testing.T) {
 	}
 }
 
+func TestPathSyntax_ModifierThisChained(t *testing.T) {
+	data := []byte(`{"arr":[{"name":"a"},{"name":"b"},{"name":"c"}],"n":[3,1,2]}`)
+	tests := []struct {
+		path     string
+		want     string
+		wantType ValueType
+	}{
+		{"arr|@this", `[{"name":"a"},{"name":"b"},{"name":"c"}]`, TypeArray},
+		{"arr.#.name|@this", `["a","b","c"]`, TypeArray},
+		{"arr.#.name|@this|@reverse", `["c","b","a"]`, TypeArray},
+		{"arr.#.name|@reverse|@this", `["c","b","a"]`, TypeArray},
+		{"arr|@this|@length", `3`, TypeNumber},
+		{"n|@reverse|@this|@length", `3`, TypeNumber},
+		{"arr|@this|1.name", `"b"`, TypeString},
+		{"arr.0.name|@this", `"a"`, TypeString},
+		{"@this|n|@this", `[3,1,2]`, TypeArray},
+	}
+	for _, tt := range tests {
+		got := Get(data, tt.path)
+		if string(got.Raw) != tt.want || got.Type != tt.wantType {
+			t.Errorf("Get(%q) = %s (%v), want %s (%v)", tt.path, got.Raw, got.Type, tt.want, tt.wantType)
+		}
+	}
+}
+
 func TestPathSyntax_ModifierValid(t *testing.T) {
 	result := Get([]byte(`{"a":1}`), "@valid")
 	if !result.Exists() {
@@ -9864,6 +10132,27 @@ func TestPathSyntax_ModifierUgly(t *testing.T) {
 	}
 }
 
+func TestPathSyntax_ModifierFormatSubDocument(t *testing.T) {
+	data := []byte(`{"user":{"name":"Ann","tags":["a"]},"payload":{ "id" : [ 1 , 2 ] },"n": 5 ,"s":"x"}`)
+	tests := []struct {
+		path     string
+		want     string
+		wantType ValueType
+	}{
+		{"user.@pretty", "{\n  \"name\": \"Ann\",\n  \"tags\": [\n    \"a\"\n  ]\n}", TypeObject},
+		{"payload.@ugly", `{"id":[1,2]}`, TypeObject},
+		{"payload.id.@ugly", `[1,2]`, TypeArray},
+		{"n.@pretty", `5`, TypeNumber},
+		{"s.@ugly", `"x"`, TypeString},
+	}
+	for _, tt := range tests {
+		r := Get(data, tt.path)
+		if string(r.Raw) != tt.want || r.Type != tt.wantType {
+			t.Errorf("Get(%q) = %q (type %v), want %q (type %v)", tt.path, r.Raw, r.Type, tt.want, tt.wantType)
+		}
+	}
+}
+
 func TestPathSyntax_JSONLines(t *testing.T) {
 	jsonLines := `{"name": "Gilbert", "age": 61}
 {"name": "Alexa", "age": 34}
@@ -10133,12 +10422,54 @@ func TestResult_Uint(t *testing.T) {
 			path: "value",
 			want: 18446744073709551615,
 		},
+		{
+			name: "zero",
+			json: `{"value":0}`,
+			path: "value",
+			want: 0,
+		},
+		{
+			name: "negative_clamps_to_zero",
+			json: `{"value":-456}`,
+			path: "value",
+			want: 0,
+		},
+		{
+			name: "negative_float_clamps_to_zero",
+			json: `{"value":-0.5}`,
+			path: "value",
+			want: 0,
+		},
+		{
+			name: "float_truncates",
+			json: `{"value":123.956}`,
+			path: "value",
+			want: 123,
+		},
+		{
+			name: "exponent",
+			json: `{"value":1.5e3}`,
+			path: "value",
+			want: 1500,
+		},
 		{
 			name: "string_number",
 			json: `{"value":"123"}`,
 			path: "value",
 			want: 123,
 		},
+		{
+			name: "negative_string",
+			json: `{"value":"-789"}`,
+			path: "value",
+			want: 0,
+		},
+		{
+			name: "invalid_string",
+			json: `{"value":"not_a_number"}`,
+			path: "value",
+			want: 0,
+		},
 		{
 			name: "bool_true",
 			json: `{"value":true}`,
@@ -10550,3 +10881,2838 @@ func TestAggregateModifiersFastPath(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePrefix(t *testing.T) {
+	data := []byte(`{"a":1}{"b":2}`)
+
+	first, n, err := ParsePrefix(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 7 {
+		t.Errorf("Expected 7 bytes consumed, got %d", n)
+	}
+	if !first.IsObject() || first.Get("a").Int() != 1 {
+		t.Errorf("Expected first object with a=1, got %s", first.Raw)
+	}
+
+	second, m, err := ParsePrefix(data[n:])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m != 7 || second.Get("b").Int() != 2 {
+		t.Errorf("Expected second object with b=2 (7 bytes), got %s (%d bytes)", second.Raw, m)
+	}
+
+	t.Run("scalars_and_whitespace", func(t *testing.T) {
+		r, n, err := ParsePrefix([]byte(` 42 "x"`))
+		if err != nil || n != 3 || r.Int() != 42 {
+			t.Errorf("Expected 42 after 3 bytes, got %v (%d bytes, err %v)", r.Value(), n, err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, in := range []string{``, `{"a":}`, `[1,2`, `tru`, `{"a":1,}`} {
+			if _, _, err := ParsePrefix([]byte(in)); err == nil {
+				t.Errorf("Expected error for %q", in)
+			}
+		}
+	})
+}
+
+func TestDescribeModifier(t *testing.T) {
+	json := []byte(`{"scores":[4,8,15,16,23,42],"empty":[]}`)
+
+	desc := Get(json, "scores|@describe")
+	if !desc.IsObject() {
+		t.Fatalf("Expected object, got %s", desc.Raw)
+	}
+	expected := map[string]float64{"count": 6, "min": 4, "max": 42, "sum": 108, "avg": 18}
+	for field, want := range expected {
+		if got := desc.Get(field).Float(); got != want {
+			t.Errorf("%s: expected %v, got %v", field, want, got)
+		}
+	}
+
+	empty := Get(json, "empty|@describe")
+	if empty.Get("count").Int() != 0 {
+		t.Errorf("Expected count 0, got %s", empty.Get("count").Raw)
+	}
+	for _, field := range []string{"min", "max", "sum", "avg"} {
+		if !empty.Get(field).IsNull() {
+			t.Errorf("%s: expected null for empty array, got %s", field, empty.Get(field).Raw)
+		}
+	}
+
+	if Get(json, "scores.0|@describe").Exists() {
+		t.Error("Expected undefined for non-array input")
+	}
+}
+
+func TestModifierAfterDot(t *testing.T) {
+	json := []byte(`{"config":{"host":"localhost","port":8080,"debug":true},"tags":["a","b"]}`)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"object_length", "config.@length", "3"},
+		{"keys_length", "config.@keys.@length", "3"},
+		{"values_first", "config.@values.@first", "localhost"},
+		{"keys_then_pipe", "config.@keys|@first", "debug"},
+		{"array_length", "tags.@length", "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if result.String() != tt.want {
+				t.Errorf("Get(%q) = %q, want %q", tt.path, result.String(), tt.want)
+			}
+		})
+	}
+
+	if r := Get([]byte(`{"o":{"@id":"x1"}}`), "o.@id"); r.String() != "x1" {
+		t.Errorf("Expected '@id' key lookup, got %q", r.String())
+	}
+
+	// Real members named like a modifier win over the modifier
+	ld := []byte(`{"obj":{"@type":"Person","@keys":{"a":1},"@this":5,"name":"Ann"}}`)
+	keyTests := []struct {
+		path string
+		want string
+	}{
+		{"obj.@type", `"Person"`},
+		{"obj.@this", `5`},
+		{"obj.@keys", `{"a":1}`},
+		{"obj.@keys.a", `1`},
+		{"obj.@keys.@length", `1`},
+		{"obj.@length", `4`},
+		{"obj|@type", `"object"`},
+	}
+	for _, tt := range keyTests {
+		if got := Get(ld, tt.path).Raw; string(got) != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestArrayLen(t *testing.T) {
+	json := []byte(`{"empty":[],"one":[1],"nested":[[1,2],{"a":[3]},"x,y"],"obj":{"a":1},"s":"str"}`)
+
+	tests := []struct {
+		path   string
+		want   int
+		wantOK bool
+	}{
+		{"empty", 0, true},
+		{"one", 1, true},
+		{"nested", 3, true},
+		{"nested.0", 2, true},
+		{"obj", 0, false},
+		{"s", 0, false},
+		{"missing", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			n, ok := ArrayLen(json, tt.path)
+			if n != tt.want || ok != tt.wantOK {
+				t.Errorf("ArrayLen(%q) = (%d, %v), want (%d, %v)", tt.path, n, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	large := []byte("[" + strings.Repeat("1,", 999) + "1]")
+	if n, ok := ArrayLen(large, ""); n != 1000 || !ok {
+		t.Errorf("Expected 1000 elements at root, got (%d, %v)", n, ok)
+	}
+}
+
+func TestCommafyModifier(t *testing.T) {
+	json := []byte(`{"revenue":1234567,"small":999,"neg":-1234,"price":1234567.891,"name":"x"}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"revenue.@commafy", "1,234,567"},
+		{"small|@commafy", "999"},
+		{"neg|@commafy", "-1,234"},
+		{"price|@commafy", "1,234,567.891"},
+		{"revenue|@commafy:.", "1.234.567"},
+		{"price|@commafy:.", "1.234.567,891"},
+		{"revenue|@commafy:_", "1_234_567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if result.Type != TypeString || result.Str != tt.want {
+				t.Errorf("Get(%q) = %v %q, want string %q", tt.path, result.Type, result.Str, tt.want)
+			}
+		})
+	}
+
+	if r := Get(json, "name|@commafy"); r.Str != "x" {
+		t.Errorf("Expected non-numeric value unchanged, got %q", r.Str)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	json := []byte(`{"a":{"b.c":1,"10":[true,"x"]},"d":null}`)
+
+	var paths []string
+	Walk(json, func(path string, value Result) bool {
+		paths = append(paths, path)
+		if path != "" && Get(json, path).Type != value.Type {
+			t.Errorf("Path %q does not resolve to the visited value", path)
+		}
+		return true
+	})
+
+	want := []string{"", "a", `a.b\.c`, "a.:10", "a.:10.0", "a.:10.1", "d"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected paths %v, got %v", want, paths)
+	}
+
+	visited := 0
+	Walk(json, func(path string, value Result) bool {
+		visited++
+		return visited < 3
+	})
+	if visited != 3 {
+		t.Errorf("Expected walk to stop after 3 values, visited %d", visited)
+	}
+}
+
+func TestAllOfType(t *testing.T) {
+	json := []byte(`{
+		"name": "store",
+		"open": true,
+		"items": [
+			{"id": 1, "title": "pen", "price": 1.5, "tags": ["office", "cheap"]},
+			{"id": 2, "title": "book", "price": 12, "meta": {"pages": 300, "isbn": null}}
+		]
+	}`)
+
+	numbers := AllOfType(json, TypeNumber)
+	if len(numbers) != 5 {
+		t.Errorf("Expected 5 numbers, got %d", len(numbers))
+	}
+	var sum float64
+	for _, n := range numbers {
+		sum += n.Num
+	}
+	if sum != 316.5 {
+		t.Errorf("Expected numbers to sum to 316.5, got %v", sum)
+	}
+
+	strs := AllOfType(json, TypeString)
+	if len(strs) != 5 {
+		t.Errorf("Expected 5 strings, got %d", len(strs))
+	}
+	if strs[0].Str != "store" || strs[len(strs)-1].Str != "book" {
+		t.Errorf("Expected strings in document order, got first %q last %q", strs[0].Str, strs[len(strs)-1].Str)
+	}
+
+	if n := len(AllOfType(json, TypeNull)); n != 1 {
+		t.Errorf("Expected 1 null, got %d", n)
+	}
+	if AllOfType([]byte(`not json`), TypeString) != nil {
+		t.Error("Expected nil for invalid input")
+	}
+}
+
+func TestGetWithOptions_AllowNonFiniteNumbers(t *testing.T) {
+	json := []byte(`{"nan":NaN,"inf":Infinity,"neg":-Infinity,"list":[1,NaN,-Infinity],"s":"NaN","n":-2}`)
+	opts := &GetOptions{AllowNonFiniteNumbers: true}
+
+	if r := GetWithOptions(json, "nan", opts); r.Type != TypeNumber || !math.IsNaN(r.Float()) || string(r.Raw) != "NaN" {
+		t.Errorf("Expected NaN, got %v %s", r.Type, r.Raw)
+	}
+	if r := GetWithOptions(json, "inf", opts); !math.IsInf(r.Float(), 1) || string(r.Raw) != "Infinity" {
+		t.Errorf("Expected +Inf, got %v %s", r.Float(), r.Raw)
+	}
+	if r := GetWithOptions(json, "neg", opts); !math.IsInf(r.Float(), -1) {
+		t.Errorf("Expected -Inf, got %v", r.Float())
+	}
+	if r := GetWithOptions(json, "list.2", opts); !math.IsInf(r.Float(), -1) {
+		t.Errorf("Expected -Inf in array, got %v", r.Float())
+	}
+	if r := GetWithOptions(json, "list", opts); string(r.Raw) != "[1,NaN,-Infinity]" {
+		t.Errorf("Expected original array bytes, got %s", r.Raw)
+	}
+	if r := GetWithOptions(json, "s", opts); r.Type != TypeString || r.Str != "NaN" {
+		t.Errorf("Expected string untouched, got %v %q", r.Type, r.Str)
+	}
+	if r := GetWithOptions(json, "n", opts); r.Float() != -2 {
+		t.Errorf("Expected -2, got %v", r.Float())
+	}
+
+	// Non-finite values survive modifiers and element iteration
+	doc := []byte(`{"a":[NaN,1,Infinity],"o":{"x":NaN}}`)
+	for path, want := range map[string]string{
+		"o|@values":  `[NaN]`,
+		"a|@reverse": `[Infinity,1,NaN]`,
+		"a|@sum":     `NaN`,
+		"a.#(>0)#":   `[1,Infinity]`,
+	} {
+		if r := GetWithOptions(doc, path, opts); string(r.Raw) != want {
+			t.Errorf("%s: expected %s, got %s", path, want, r.Raw)
+		}
+	}
+	if r := GetWithOptions(doc, "a|@max", opts); !math.IsNaN(r.Float()) {
+		t.Errorf("a|@max: expected NaN, got %v", r.Float())
+	}
+	if r := GetWithOptions([]byte(`[1,Infinity]`), "@max", opts); !math.IsInf(r.Float(), 1) || string(r.Raw) != "Infinity" {
+		t.Errorf("@max: expected Infinity, got %s", r.Raw)
+	}
+	elems := GetWithOptions(doc, "a", opts).Array()
+	if len(elems) != 3 || !math.IsNaN(elems[0].Float()) || !math.IsInf(elems[2].Float(), 1) {
+		t.Errorf("Expected [NaN 1 +Inf] elements, got %v", elems)
+	}
+
+	for _, path := range []string{"nan", "inf", "neg", "list.1"} {
+		if r := GetWithOptions(json, path, nil); r.Type == TypeNumber {
+			t.Errorf("%s: expected rejection without the flag, got number", path)
+		}
+		if r := GetWithOptions(json, path, &GetOptions{}); r.Type == TypeNumber {
+			t.Errorf("%s: expected rejection with zero options, got number", path)
+		}
+	}
+	if Valid(json) {
+		t.Error("Valid should reject non-finite tokens")
+	}
+}
+
+func TestForEach_Projection(t *testing.T) {
+	json := []byte(`{"users":[
+		{"name":"alice","profile":{"city":"Paris","age":31}},
+		{"name":"bob","profile":{"city":"Rome","age":27}},
+		{"name":"carol","profile":{"city":"Oslo","age":45}}
+	]}`)
+
+	profiles := Get(json, "users.#.profile")
+	if !profiles.IsArray() {
+		t.Fatalf("Expected projected array, got %v", profiles.Type)
+	}
+
+	wantCities := []string{"Paris", "Rome", "Oslo"}
+	wantAges := []int64{31, 27, 45}
+	count := 0
+	profiles.ForEach(func(key, value Result) bool {
+		if key.Type != TypeNumber || key.Int() != int64(count) {
+			t.Errorf("Expected index key %d, got %v %q", count, key.Type, key.Str)
+		}
+		if city := value.Get("city").String(); city != wantCities[count] {
+			t.Errorf("Element %d: expected city %s, got %s", count, wantCities[count], city)
+		}
+		if age := value.Get("age").Int(); age != wantAges[count] {
+			t.Errorf("Element %d: expected age %d, got %d", count, wantAges[count], age)
+		}
+		count++
+		return true
+	})
+	if count != 3 {
+		t.Errorf("Expected 3 iterations, got %d", count)
+	}
+
+	// Single-element projections still iterate as arrays
+	single := Get([]byte(`{"users":[{"profile":{"city":"Lima"}}]}`), "users.#.profile")
+	visited := 0
+	single.ForEach(func(key, value Result) bool {
+		visited++
+		if key.Int() != 0 || value.Get("city").String() != "Lima" {
+			t.Errorf("Unexpected single element %s=%s", key.Str, value.Raw)
+		}
+		return true
+	})
+	if visited != 1 {
+		t.Errorf("Expected 1 iteration over single-element projection, got %d", visited)
+	}
+}
+
+func TestCompileQuery(t *testing.T) {
+	docs := [][]byte{
+		[]byte(`{"items":[{"name":"pen","price":2},{"name":"book","price":12},{"name":"cup","price":5}],"tags":["b","a"]}`),
+		[]byte(`{"items":[{"name":"lamp","price":30},{"name":"clip","price":1}],"tags":[]}`),
+		[]byte(`{"other":true}`),
+		[]byte(``),
+	}
+	paths := []string{
+		"items.#(price<10)#.name|@sort",
+		"items.#(price>10).name",
+		"items.#.price|@sum",
+		"items.1.name",
+		"tags|@reverse",
+		"tags.@length",
+		"items.0.name,tags.0",
+		"other",
+		"@this",
+	}
+
+	for _, path := range paths {
+		q, err := CompilePath(path)
+		if err != nil {
+			t.Fatalf("CompilePath(%q) failed: %v", path, err)
+		}
+		if q.String() != path {
+			t.Errorf("Expected String() %q, got %q", path, q.String())
+		}
+		for _, doc := range docs {
+			want := Get(doc, path)
+			got := q.Eval(doc)
+			if got.Type != want.Type || string(got.Raw) != string(want.Raw) {
+				t.Errorf("Eval(%q) on %s = %v %s, Get = %v %s", path, doc, got.Type, got.Raw, want.Type, want.Raw)
+			}
+			if got := GetWithCompiledPath(doc, q); got.Type != want.Type || string(got.Raw) != string(want.Raw) {
+				t.Errorf("GetWithCompiledPath(%q) on %s = %v %s, Get = %v %s", path, doc, got.Type, got.Raw, want.Type, want.Raw)
+			}
+		}
+	}
+
+	if _, err := CompilePath(""); err != ErrInvalidQuery {
+		t.Errorf("Expected ErrInvalidQuery for empty path, got %v", err)
+	}
+	var nilPath *Path
+	if nilPath.Eval(docs[0]).Exists() || GetWithCompiledPath(docs[0], nilPath).Exists() {
+		t.Error("Expected undefined result from nil path")
+	}
+
+	// Compile and CompilePath build the same Query
+	if q, err := Compile("items.1.name"); err != nil || GetWithCompiledPath(docs[0], q).String() != Get(docs[0], "items.1.name").String() {
+		t.Errorf("Compile() = %v, %v", q, err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	json := []byte(`{
+		"id": 0,
+		"users": [
+			{"id": 1, "email": "a@x.io", "profile": {"id": 10}},
+			{"id": 2, "email": "b@x.io"}
+		],
+		"admins": {"root": {"email": "r@x.io"}},
+		"db_main": {"host": "h1"},
+		"db_replica": {"host": "h2"}
+	}`)
+
+	assertPaths := func(t *testing.T, glob string, want ...string) map[string]Result {
+		t.Helper()
+		got := Match(json, glob)
+		if len(got) != len(want) {
+			t.Errorf("Match(%q) returned %d paths, want %d: %v", glob, len(got), len(want), got)
+		}
+		for _, path := range want {
+			if _, ok := got[path]; !ok {
+				t.Errorf("Match(%q) missing path %q", glob, path)
+			}
+		}
+		return got
+	}
+
+	t.Run("single_level", func(t *testing.T) {
+		got := assertPaths(t, "users.*.email", "users.0.email", "users.1.email")
+		if got["users.1.email"].String() != "b@x.io" {
+			t.Errorf("Unexpected value %s", got["users.1.email"].Raw)
+		}
+		assertPaths(t, "*.root.email", "admins.root.email")
+		assertPaths(t, "db_*.host", "db_main.host", "db_replica.host")
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		got := assertPaths(t, "**.id", "id", "users.0.id", "users.1.id", "users.0.profile.id")
+		if got["users.0.profile.id"].Int() != 10 {
+			t.Errorf("Unexpected nested id %s", got["users.0.profile.id"].Raw)
+		}
+		assertPaths(t, "**.email", "users.0.email", "users.1.email", "admins.root.email")
+		assertPaths(t, "users.**.id", "users.0.id", "users.1.id", "users.0.profile.id")
+	})
+
+	t.Run("no_match", func(t *testing.T) {
+		assertPaths(t, "users.*.phone")
+		assertPaths(t, "")
+	})
+}
+
+func TestGetWithOptions_ParseIntStrings(t *testing.T) {
+	json := []byte(`{"hex":"0x1F","oct":"0o17","bin":"0b101","neg":"-0x10","dec":"42","bad":"0xZZ","word":"hello","big":"0xFFFFFFFFFFFFFFFF"}`)
+	opts := &GetOptions{ParseIntStrings: true}
+
+	tests := []struct {
+		path     string
+		wantType ValueType
+		want     int64
+	}{
+		{"hex", TypeNumber, 31},
+		{"oct", TypeNumber, 15},
+		{"bin", TypeNumber, 5},
+		{"neg", TypeNumber, -16},
+		{"dec", TypeString, 42},
+		{"bad", TypeString, 0},
+		{"word", TypeString, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := GetWithOptions(json, tt.path, opts)
+			if r.Type != tt.wantType {
+				t.Errorf("Type = %v, want %v", r.Type, tt.wantType)
+			}
+			if got := r.Int(); got != tt.want {
+				t.Errorf("Int() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if r := GetWithOptions(json, "hex", opts); r.Float() != 31 || r.Uint() != 31 || r.String() != "31" || r.NumberString() != "31" {
+		t.Errorf("Expected 31, got %v/%d/%q/%q", r.Float(), r.Uint(), r.String(), r.NumberString())
+	}
+	if got := GetWithOptions(json, "big", opts).Uint(); got != math.MaxUint64 {
+		t.Errorf("Uint() = %d, want %d", got, uint64(math.MaxUint64))
+	}
+
+	// A string result with a stray Num is still read as its text
+	if got := (Result{Type: TypeString, Str: "0x1F", Num: 31}).Int(); got != 0 {
+		t.Errorf("Expected 0 for a string result, got %d", got)
+	}
+
+	// Default behavior stays decimal-only
+	if got := Get(json, "hex").Int(); got != 0 {
+		t.Errorf("Expected 0 for hex string without flag, got %d", got)
+	}
+	if got := GetWithOptions(json, "hex", &GetOptions{}).Int(); got != 0 {
+		t.Errorf("Expected 0 for hex string with zero options, got %d", got)
+	}
+	if got := Get(json, "dec").Int(); got != 42 {
+		t.Errorf("Expected decimal string to parse by default, got %d", got)
+	}
+}
+
+func TestResult_FieldItem(t *testing.T) {
+	doc := Parse([]byte(`{"user":{"name":"Ann","tags":["a","b"],"a.b":1,"7":"seven"},"rows":[{"id":1},{"id":2}]}`))
+
+	if got := doc.Field("user").Field("name").String(); got != "Ann" {
+		t.Errorf("Field chain = %q, want Ann", got)
+	}
+	if got := doc.Field("user").Field("tags").Item(1).String(); got != "b" {
+		t.Errorf("Item(1) = %q, want b", got)
+	}
+	if got := doc.Field("user").Field("a.b").Int(); got != 1 {
+		t.Errorf("Field with dot = %d, want 1", got)
+	}
+	if got := doc.Field("user").Field("7").String(); got != "seven" {
+		t.Errorf("Field numeric key = %q, want seven", got)
+	}
+	if doc.Field("rows").Item(5).Exists() || doc.Field("rows").Item(-1).Exists() {
+		t.Error("Expected out of range Item to not exist")
+	}
+	if doc.Field("rows").Field("0").Exists() || doc.Field("user").Item(0).Exists() {
+		t.Error("Expected Field on array and Item on object to not exist")
+	}
+	if doc.Field("missing").Field("name").Exists() {
+		t.Error("Expected chain through missing field to not exist")
+	}
+
+	tmpl := template.Must(template.New("t").Parse(
+		`{{(.Field "user").Field "name"}}:{{range (.Field "rows").Array}}{{.Field "id"}}{{end}}:{{((.Field "user").Field "tags").Item 0}}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, doc); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if buf.String() != "Ann:12:a" {
+		t.Errorf("template output = %q, want %q", buf.String(), "Ann:12:a")
+	}
+}
+
+func TestResultChild(t *testing.T) {
+	doc := Parse([]byte(`{"users":[{"name":"Ann","tags":["a","b"]},{"name":"Bo"}],"a.b":1,"7":"seven","-1":"neg"}`))
+
+	// The same call shape walks objects and arrays alike
+	path := []string{"users", "0", "tags", "1"}
+	cur := doc
+	for _, step := range path {
+		cur = cur.Child(step)
+	}
+	if cur.String() != "b" {
+		t.Errorf("walk %v = %q, want b", path, cur.String())
+	}
+
+	tests := []struct {
+		child Result
+		want  string
+	}{
+		{doc.Child("users").Child("1").Child("name"), `"Bo"`},
+		{doc.Child("users").Child("-1").Child("name"), `"Bo"`},
+		{doc.Child("a.b"), `1`},
+		{doc.Child("7"), `"seven"`},
+		{doc.Child("-1"), `"neg"`},
+		{doc.Child("users").Child("2"), ``},
+		{doc.Child("users").Child("name"), ``},
+		{doc.Child("users").Child("0").Child("name").Child("x"), ``},
+		{doc.Child("missing").Child("0"), ``},
+		{doc.Child("*"), ``},
+	}
+	for i, tt := range tests {
+		if got := string(tt.child.Raw); got != tt.want {
+			t.Errorf("case %d: Child = %s, want %s", i, got, tt.want)
+		}
+	}
+}
+
+func TestCollectWithPaths(t *testing.T) {
+	json := []byte(`{
+		"id": 1,
+		"users": [
+			{"id": 2, "name": "a", "posts": [{"id": 3}, {"title": "x"}]},
+			{"id": {"id": 4}}
+		],
+		"meta": {"a.b": {"id": 5}, "ids": [6]}
+	}`)
+
+	got := CollectWithPaths(json, "id")
+	want := []struct {
+		path string
+		raw  string
+	}{
+		{"id", "1"},
+		{"users.0.id", "2"},
+		{"users.0.posts.0.id", "3"},
+		{"users.1.id", `{"id": 4}`},
+		{"users.1.id.id", "4"},
+		{`meta.a\.b.id`, "5"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("CollectWithPaths() returned %d results, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Path != w.path || string(got[i].Value.Raw) != w.raw {
+			t.Errorf("result %d = {%q, %q}, want {%q, %q}", i, got[i].Path, got[i].Value.Raw, w.path, w.raw)
+		}
+		if r := Get(json, got[i].Path); string(r.Raw) != w.raw {
+			t.Errorf("Get(%q) = %q, want %q", got[i].Path, r.Raw, w.raw)
+		}
+	}
+
+	if res := CollectWithPaths(json, "missing"); len(res) != 0 {
+		t.Errorf("Expected no results for missing key, got %v", res)
+	}
+	if res := CollectWithPaths([]byte(`[1,2]`), "id"); len(res) != 0 {
+		t.Errorf("Expected no results for scalar array, got %v", res)
+	}
+}
+
+func TestChunkModifier(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`{"items":[`)
+	for i := 0; i < 250; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.Itoa(i))
+	}
+	sb.WriteString(`],"small":[1,2,3],"empty":[],"name":"x"}`)
+	json := []byte(sb.String())
+
+	chunks := Get(json, "items|@chunk:100")
+	if !chunks.IsArray() {
+		t.Fatalf("Expected array of chunks, got %v", chunks.Type)
+	}
+	var sizes []int
+	chunks.ForEach(func(_, chunk Result) bool {
+		sizes = append(sizes, len(chunk.Array()))
+		return true
+	})
+	if fmt.Sprint(sizes) != "[100 100 50]" {
+		t.Errorf("chunk sizes = %v, want [100 100 50]", sizes)
+	}
+	if got := chunks.Get("2.0").Int(); got != 200 {
+		t.Errorf("first element of last chunk = %d, want 200", got)
+	}
+	if got := chunks.Get("1.99").Int(); got != 199 {
+		t.Errorf("last element of second chunk = %d, want 199", got)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"small|@chunk:10", `[[1,2,3]]`},
+		{"small.@chunk:2", `[[1,2],[3]]`},
+		{"empty|@chunk:2", `[]`},
+		{"small|@chunk:0", `[1,2,3]`},
+		{"small|@chunk:abc", `[1,2,3]`},
+		{"name|@chunk:2", `"x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := string(Get(json, tt.path).Raw); got != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		want   ValueType
+		wantOK bool
+	}{
+		{"object", ` {"a":[1,2]} `, TypeObject, true},
+		{"array", `[{"a":1}]`, TypeArray, true},
+		{"string", `"hi"`, TypeString, true},
+		{"number", `-12.5e3`, TypeNumber, true},
+		{"true", `true`, TypeBoolean, true},
+		{"false", "\nfalse\n", TypeBoolean, true},
+		{"null", `null`, TypeNull, true},
+		{"empty", ``, TypeUndefined, false},
+		{"whitespace", `   `, TypeUndefined, false},
+		{"unterminated_object", `{"a":1`, TypeUndefined, false},
+		{"invalid_nested", `[1,}`, TypeUndefined, false},
+		{"bad_literal", `nul`, TypeUndefined, false},
+		{"trailing_content", `{} {}`, TypeUndefined, false},
+		{"unknown_start", `@x`, TypeUndefined, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Kind([]byte(tt.json))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Kind(%q) = (%v, %v), want (%v, %v)", tt.json, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetWithSeparator(t *testing.T) {
+	json := []byte(`{
+		"hosts": {
+			"api.example.com": {"port": 443, "tags": ["a", "b"]},
+			"db/primary": {"port": 5432}
+		},
+		"version.major": 2,
+		"items": [{"v.1": "x"}, {"v.1": "y"}]
+	}`)
+
+	tests := []struct {
+		path string
+		sep  rune
+		want string
+	}{
+		{"hosts/api.example.com/port", '/', "443"},
+		{"hosts/api.example.com/tags/1", '/', `"b"`},
+		{"version.major", '/', "2"},
+		{`hosts/db\/primary/port`, '/', "5432"},
+		{"items/#/v.1", '/', `["x","y"]`},
+		{"hosts/api.example.com/tags|@reverse", '/', `["b","a"]`},
+		{"hosts>api.example.com>port", '>', "443"},
+		{"hosts.db/primary.port", '.', "5432"},
+		{"hosts/missing/port", '/', ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := string(GetWithSeparator(json, tt.path, tt.sep).Raw); got != tt.want {
+				t.Errorf("GetWithSeparator(%q, %q) = %s, want %s", tt.path, tt.sep, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResult_Document(t *testing.T) {
+	json := []byte(`{"user":{"name":"Ann","roles":["admin","dev"],"address":{"city":"Paris"}}}`)
+	user := Get(json, "user")
+	doc := user.Document()
+
+	for _, path := range []string{"name", "roles.1", "roles.#", "address.city", "roles|@reverse", "missing"} {
+		want := user.Get(path)
+		got := Get(doc, path)
+		if got.Type != want.Type || string(got.Raw) != string(want.Raw) {
+			t.Errorf("Get(Document(), %q) = %s, want %s", path, got.Raw, want.Raw)
+		}
+	}
+
+	// The document is independent of the original input
+	copy(json, bytes.Repeat([]byte(" "), len(json)))
+	if got := Get(doc, "address.city").String(); got != "Paris" {
+		t.Errorf("Expected document to survive input reuse, got %q", got)
+	}
+
+	if doc := Get(json, "missing").Document(); doc != nil {
+		t.Errorf("Expected nil document for missing result, got %q", doc)
+	}
+}
+
+func TestQueryFieldComparison(t *testing.T) {
+	// The "active" member must not turn the literal into a field reference
+	users := []byte(`{"u":[{"id":1,"status":"active","active":"no"},{"id":2,"status":"off","active":"off"}]}`)
+	if got := string(Get(users, "u.#(status==active)#.id").Raw); got != `[1]` {
+		t.Errorf("Get(u.#(status==active)#.id) = %s, want [1]", got)
+	}
+
+	json := []byte(`{"items":[
+		{"name":"a","price":10,"cost":4,"min":1,"max":1},
+		{"name":"b","price":3,"cost":5,"min":2,"max":9},
+		{"name":"c","price":7,"cost":7,"min":"x","max":"y"},
+		{"name":"d","price":12,"cost":2.5,"min":5}
+	]}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"items.#(price>@.cost)#.name", `["a","d"]`},
+		{"items.#(price<@.cost)#.name", `["b"]`},
+		{"items.#(price>=@.cost)#.name", `["a","c","d"]`},
+		{"items.#(price==@.cost).name", `"c"`},
+		{"items.#(max>@.min)#.name", `["b","c"]`},
+		// A missing reference matches nothing, even with !=
+		{"items.#(min!=@.max)#.name", `["b","c"]`},
+		// Literals are still compared as values
+		{"items.#(price>5)#.name", `["a","c","d"]`},
+		{"items.#(cost==2.5).name", `"d"`},
+		{`items.#(name=="cost").name`, ``},
+		// A bare word is always a string literal, even when it names a field
+		{"items.#(name==b).price", `3`},
+		{"items.#(name==cost)#.name", ``},
+		{"items.#(price>cost)#.name", ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := string(Get(json, tt.path).Raw); got != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDoc(t *testing.T) {
+	json := []byte(`{
+		"name": "Ann",
+		"age": 31,
+		"tags": ["a", "b", "c"],
+		"address": {"city": "Paris", "zip": "75001"},
+		"friends": [{"name": "Bob", "age": 40}, {"name": "Cy", "age": 25}],
+		"a.b": 1,
+		"7": "seven",
+		"name": "dup"
+	}`)
+
+	doc, err := ParseDoc(json)
+	if err != nil {
+		t.Fatalf("ParseDoc() error = %v", err)
+	}
+
+	paths := []string{
+		"name", "age", "tags", "tags.1", "tags.#", "address.city", "address",
+		"friends.#.name", "friends.#(age>30).name", "friends.1.age", "tags|@reverse",
+		"tags.@length", `a\.b`, "7", "missing", "missing.deep", "address.missing",
+		"{name,age}", "fr*.0.name", "@this",
+		"address.city,name", "name,address.city", "address..city", "friends..name",
+		"address|@keys", "tags|0", "..name", "..address.city",
+	}
+	for _, path := range paths {
+		want := Get(json, path)
+		got := doc.Get(path)
+		if got.Type != want.Type || string(got.Raw) != string(want.Raw) || got.Index != want.Index {
+			t.Errorf("Doc.Get(%q) = {%v %s %d}, want {%v %s %d}", path, got.Type, got.Raw, got.Index, want.Type, want.Raw, want.Index)
+		}
+	}
+
+	arr, err := ParseDoc([]byte(`[1,{"a":2}]`))
+	if err != nil {
+		t.Fatalf("ParseDoc(array) error = %v", err)
+	}
+	if got := arr.Get("1.a").Int(); got != 2 {
+		t.Errorf("Doc.Get on array root = %d, want 2", got)
+	}
+
+	for _, invalid := range []string{``, `{"a":`, `{"a":1} x`} {
+		if _, err := ParseDoc([]byte(invalid)); err != ErrInvalidJSON {
+			t.Errorf("ParseDoc(%q) error = %v, want ErrInvalidJSON", invalid, err)
+		}
+	}
+
+	// Keys written with escapes match by their decoded form, as with Get
+	for _, raw := range []string{`{"a\u0062":1,"ab":2}`, `{"ab":1,"a\u0062":2}`, `{"a\u0062":{"c":3}}`} {
+		escaped, err := ParseDoc([]byte(raw))
+		if err != nil {
+			t.Fatalf("ParseDoc(%s) error = %v", raw, err)
+		}
+		for _, path := range []string{"ab", "ab.c"} {
+			want := Get([]byte(raw), path)
+			if got := escaped.Get(path); string(got.Raw) != string(want.Raw) {
+				t.Errorf("Doc.Get(%q) on %s = %s, want %s", path, raw, got.Raw, want.Raw)
+			}
+		}
+	}
+
+	// Offsets within a member are reported relative to the document
+	got := rebaseResult(Result{Index: 4, Indexes: []int{1, 7}}, 10)
+	if got.Index != 14 || len(got.Indexes) != 2 || got.Indexes[0] != 11 || got.Indexes[1] != 17 {
+		t.Errorf("rebaseResult() = {%d %v}, want {14 [11 17]}", got.Index, got.Indexes)
+	}
+	if got := rebaseResult(Result{}, 10); got.Index != 0 {
+		t.Errorf("rebaseResult() kept an unknown offset as %d", got.Index)
+	}
+
+	var nilDoc *Doc
+	if nilDoc.Get("name").Exists() {
+		t.Error("Expected nil Doc to return an undefined result")
+	}
+}
+
+func TestTypeCoercionModifiers(t *testing.T) {
+	json := []byte(`{"count":"42","ratio":"2.5","price":19.99,"id":1001,"active":"true","flag":1,"name":"x"}`)
+
+	tests := []struct {
+		path     string
+		wantType ValueType
+		wantRaw  string
+	}{
+		{"count.@int", TypeNumber, "42"},
+		{"price.@int", TypeNumber, "19"},
+		{"name.@int", TypeNumber, "0"},
+		{"ratio.@float", TypeNumber, "2.5"},
+		{"count|@float", TypeNumber, "42"},
+		{"id.@str", TypeString, `"1001"`},
+		{"price.@str", TypeString, `"19.99"`},
+		{"active.@bool", TypeBoolean, "true"},
+		{"flag.@bool", TypeBoolean, "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := Get(json, tt.path)
+			if r.Type != tt.wantType || string(r.Raw) != tt.wantRaw {
+				t.Errorf("Get(%q) = {%v %s}, want {%v %s}", tt.path, r.Type, r.Raw, tt.wantType, tt.wantRaw)
+			}
+		})
+	}
+
+	if got := Get(json, "count.@int").Int(); got != 42 {
+		t.Errorf("count.@int Int() = %d, want 42", got)
+	}
+	if got := Get(json, "id.@str").String(); got != "1001" {
+		t.Errorf("id.@str String() = %q, want 1001", got)
+	}
+}
+
+func TestResult_ForEachReuse(t *testing.T) {
+	json := []byte(`{"arr":[1,"two",{"three":3},[4],null,true],"obj":{"a":1,"b":"x","c":[1,2]},"n":5}`)
+
+	for _, path := range []string{"arr", "obj"} {
+		r := Get(json, path)
+		var want, got []string
+		r.ForEach(func(key, value Result) bool {
+			want = append(want, key.String()+"="+string(value.Raw)+"/"+value.String())
+			return true
+		})
+		var prev *Result
+		r.ForEachReuse(func(key, value *Result) bool {
+			if prev != nil && prev != value {
+				t.Errorf("Expected the same value pointer on every call")
+			}
+			prev = value
+			got = append(got, key.String()+"="+string(value.Raw)+"/"+value.String())
+			return true
+		})
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("ForEachReuse(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	count := 0
+	Get(json, "arr").ForEachReuse(func(key, _ *Result) bool {
+		count++
+		return key.Int() < 2
+	})
+	if count != 3 {
+		t.Errorf("Expected iteration to stop after 3 elements, got %d", count)
+	}
+
+	called := false
+	Get(json, "n").ForEachReuse(func(_, _ *Result) bool {
+		called = true
+		return true
+	})
+	if called {
+		t.Error("Expected no iteration over a scalar")
+	}
+}
+
+func TestConvertPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		from, to PathSyntax
+		want     string
+	}{
+		{"dotted_to_pointer", "users.0.name", PathDotted, PathJSONPointer, "/users/0/name"},
+		{"pointer_to_dotted", "/users/0/name", PathJSONPointer, PathDotted, "users.0.name"},
+		{"escaped_dot_to_pointer", `config.first\.name`, PathDotted, PathJSONPointer, "/config/first.name"},
+		{"pointer_escapes", "/a~1b/c~0d/e.f", PathJSONPointer, PathDotted, `a/b.c\~d.e\.f`},
+		{"dotted_to_pointer_escapes", `a/b.c\~d`, PathDotted, PathJSONPointer, "/a~1b/c~0d"},
+		{"numeric_key_to_pointer", "items.:7.id", PathDotted, PathJSONPointer, "/items/7/id"},
+		{"numeric_key_to_jsonpath", "items.:7.id", PathDotted, PathJSONPath, "$.items['7'].id"},
+		{"dotted_to_jsonpath", `users.2.first\.name`, PathDotted, PathJSONPath, "$.users[2]['first.name']"},
+		{"jsonpath_to_dotted", `$.users[2]['first.name']["it's"]`, PathJSONPath, PathDotted, `users.2.first\.name.it's`},
+		{"jsonpath_quoted_key", `$['a\'b'][0]`, PathJSONPath, PathJSONPointer, "/a'b/0"},
+		{"jsonpath_numeric_key", `$['0']`, PathJSONPath, PathDotted, ":0"},
+		{"pointer_leading_zero_key", "/a/01", PathJSONPointer, PathDotted, "a.:01"},
+		{"root", "", PathDotted, PathJSONPointer, ""},
+		{"root_jsonpath", "$", PathJSONPath, PathDotted, ""},
+		{"root_to_jsonpath", "", PathJSONPointer, PathJSONPath, "$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertPath(tt.path, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("ConvertPath(%q) error = %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	// Round trip through JSON Pointer resolves to the same value
+	json := []byte(`{"users":[{"first.name":"Ann","tags":{"7":"x"}}]}`)
+	dotted := `users.0.first\.name`
+	pointer, _ := ConvertPath(dotted, PathDotted, PathJSONPointer)
+	back, _ := ConvertPath(pointer, PathJSONPointer, PathDotted)
+	if back != dotted || Get(json, back).String() != "Ann" {
+		t.Errorf("round trip = %q (%q)", back, Get(json, back).String())
+	}
+
+	invalid := []struct {
+		path string
+		from PathSyntax
+		to   PathSyntax
+	}{
+		{"users.#.name", PathDotted, PathJSONPointer},
+		{"users.*", PathDotted, PathJSONPointer},
+		{"users|@reverse", PathDotted, PathJSONPointer},
+		{"a..b", PathDotted, PathJSONPointer},
+		{"users/0", PathJSONPointer, PathDotted},
+		{"/a~2", PathJSONPointer, PathDotted},
+		{"/a//b", PathJSONPointer, PathDotted},
+		{"users[0]", PathJSONPath, PathDotted},
+		{"$.users[*]", PathJSONPath, PathDotted},
+		{"$..name", PathJSONPath, PathDotted},
+		{"$['open", PathJSONPath, PathDotted},
+		{"a", PathSyntax(9), PathDotted},
+	}
+	for _, tt := range invalid {
+		if _, err := ConvertPath(tt.path, tt.from, tt.to); err != ErrInvalidPath {
+			t.Errorf("ConvertPath(%q) error = %v, want ErrInvalidPath", tt.path, err)
+		}
+	}
+}
+
+func TestLocateMember(t *testing.T) {
+	json := []byte(`{"a":1, "user": {"first": "Ann", "mid" : [1, 2], "last":{"x":null}}, "7":true, "k.e":"v"}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"user.first", `"first": "Ann"`},
+		{"user.mid", `"mid" : [1, 2]`},
+		{"user.last", `"last":{"x":null}`},
+		{"a", `"a":1`},
+		{"user", `"user": {"first": "Ann", "mid" : [1, 2], "last":{"x":null}}`},
+		{":7", `"7":true`},
+		{`k\.e`, `"k.e":"v"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			start, end, ok := LocateMember(json, tt.path)
+			if !ok {
+				t.Fatalf("LocateMember(%q) not found", tt.path)
+			}
+			if got := string(json[start:end]); got != tt.want {
+				t.Errorf("LocateMember(%q) span = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	// Cutting the middle member together with its trailing comma
+	start, end, _ := LocateMember(json, "user.mid")
+	rest := bytes.TrimLeft(json[end:], " ")
+	moved := append(append([]byte{}, json[:start]...), bytes.TrimLeft(rest[1:], " ")...)
+	if !Valid(moved) || Get(moved, "user.mid").Exists() || Get(moved, "user.last.x").Type != TypeNull {
+		t.Errorf("Unexpected document after cut: %s", moved)
+	}
+
+	for _, path := range []string{"missing", "user.missing", "user.mid.0", "user.first.x", "user.*", ""} {
+		if _, _, ok := LocateMember(json, path); ok {
+			t.Errorf("LocateMember(%q) should not be found", path)
+		}
+	}
+	if _, _, ok := LocateMember([]byte(`[{"a":1}]`), "a"); ok {
+		t.Error("LocateMember on array root should not be found")
+	}
+}
+
+func TestResult_StringRawConsistency(t *testing.T) {
+	json := []byte(`{"plain":"value","esc":"a\"b\\c\ndé","arr":["x","t\ty"],"obj":{"s":"q\/r"}}`)
+
+	tests := []struct {
+		path    string
+		wantRaw string
+		wantStr string
+	}{
+		{"plain", `"value"`, "value"},
+		{"esc", `"a\"b\\c\ndé"`, "a\"b\\c\ndé"},
+		{"arr.0", `"x"`, "x"},
+		{"arr.1", `"t\ty"`, "t\ty"},
+		{"obj.s", `"q\/r"`, "q/r"},
+		{"arr.#(==x)", `"x"`, "x"},
+		{"esc|@this", `"a\"b\\c\ndé"`, "a\"b\\c\ndé"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := Get(json, tt.path)
+			if r.Type != TypeString {
+				t.Fatalf("Expected string, got %v", r.Type)
+			}
+			if string(r.Raw) != tt.wantRaw {
+				t.Errorf("Raw = %s, want %s", r.Raw, tt.wantRaw)
+			}
+			if r.String() != tt.wantStr {
+				t.Errorf("String() = %q, want %q", r.String(), tt.wantStr)
+			}
+		})
+	}
+
+	// Iteration and Parse agree with Get
+	Get(json, "arr").ForEach(func(key, value Result) bool {
+		if want := Get(json, "arr."+key.String()); value.String() != want.String() || string(value.Raw) != string(want.Raw) {
+			t.Errorf("ForEach element %s = %q/%s, Get = %q/%s", key.String(), value.String(), value.Raw, want.String(), want.Raw)
+		}
+		return true
+	})
+	if r := Parse([]byte(`"a\"b"`)); string(r.Raw) != `"a\"b"` || r.String() != `a"b` {
+		t.Errorf("Parse = %s/%q", r.Raw, r.String())
+	}
+}
+
+func TestSortKeysModifier(t *testing.T) {
+	json := []byte(`{"obj":{"zeta":1, "alpha":{"d":4,"b":[{"y":1,"x":2}]}, "Mid":"m", "beta":null},"arr":[{"b":1,"a":2}],"n":5}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"obj.@sortKeys", `{"Mid":"m","alpha":{"b":[{"x":2,"y":1}],"d":4},"beta":null,"zeta":1}`},
+		{"obj|@sortKeys", `{"Mid":"m","alpha":{"b":[{"x":2,"y":1}],"d":4},"beta":null,"zeta":1}`},
+		{"arr.@sortKeys", `[{"a":2,"b":1}]`},
+		{"n.@sortKeys", `5`},
+		{"obj.alpha.b.0.@sortKeys|@pretty", "{\n  \"x\": 2,\n  \"y\": 1\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := string(Get(json, tt.path).Raw); got != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	var keys []string
+	Get(json, "obj.@sortKeys").ForEach(func(key, _ Result) bool {
+		keys = append(keys, key.String())
+		return true
+	})
+	if fmt.Sprint(keys) != "[Mid alpha beta zeta]" {
+		t.Errorf("member order = %v", keys)
+	}
+}
+
+func TestKeyPrefixWildcard(t *testing.T) {
+	json := []byte(`{
+		"config": {"db_host": "localhost", "api_key": "k", "db_port": 5432, "cache_db": "x", "db": "plain"},
+		"services": {"db_main": {"port": 1}, "db_replica": {"port": 2}, "web": {"port": 3}}
+	}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"config.db_*", `["localhost",5432]`},
+		{"config.db_*|@length", `2`},
+		{"config.db_p*", `5432`},
+		{"services.db_m*.port", `1`},
+		{"config.db*", `["localhost",5432,"plain"]`},
+		{"services.db_*.port", `[1,2]`},
+		{"config.nomatch_*", ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := string(Get(json, tt.path).Raw); got != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	for _, v := range Get(json, "config.db_*").Array() {
+		if v.String() == "k" || v.String() == "x" {
+			t.Errorf("Non-matching key value %q included", v.String())
+		}
+	}
+}
+
+func TestResult_GoString(t *testing.T) {
+	json := []byte(`{"s":"value","n":42.5,"b":true,"z":null,"o":{"a":1},"arr":[1,2]}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"s", `Result{Type:string, Raw:"value", Exists:true}`},
+		{"n", `Result{Type:number, Raw:42.5, Exists:true}`},
+		{"b", `Result{Type:boolean, Raw:true, Exists:true}`},
+		{"z", `Result{Type:null, Raw:null, Exists:true}`},
+		{"o", `Result{Type:object, Raw:{"a":1}, Exists:true}`},
+		{"arr.#", `Result{Type:number, Raw:2, Exists:true}`},
+		{"missing", `Result{Type:undefined, Raw:, Exists:false}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := Get(json, tt.path)
+			if got := r.GoString(); got != tt.want {
+				t.Errorf("GoString() = %s, want %s", got, tt.want)
+			}
+			if got := fmt.Sprintf("%#v", r); got != tt.want {
+				t.Errorf("%%#v = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResult_Matches(t *testing.T) {
+	people := Parse([]byte(`[{"name":"Ann","age":42,"tags":["a"]},{"name":"Bob","age":25}]`)).Array()
+	ann, bob := people[0], people[1]
+
+	tests := []struct {
+		name   string
+		r      Result
+		filter string
+		want   bool
+	}{
+		{"greater_true", ann, "#(age>30)", true},
+		{"greater_false", bob, "#(age>30)", false},
+		{"unwrapped", bob, "age<30", true},
+		{"string_eq", ann, `#(name=="Ann")`, true},
+		{"pattern", bob, `#(name%"B*")`, true},
+		{"existence", ann, "#(tags)", true},
+		{"missing_field", bob, "#(tags)", false},
+		{"field_ref", ann, "#(age>name)", false},
+		{"nested_query", ann, `#(tags.#(=="a"))`, true},
+		{"scalar_self", Parse([]byte(`5`)), "#(>3)", true},
+		{"empty_string", Parse([]byte(`{"s":""}`)), `#(s=="")`, true},
+		{"undefined_result", Result{}, "#(age>30)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.Matches(tt.filter)
+			if err != nil {
+				t.Fatalf("Matches(%q) error = %v", tt.filter, err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "#()", "#(age>30", "#(age>)", "#((age>30)", `#(name=="Ann)`} {
+		if _, err := ann.Matches(bad); err != ErrInvalidQuery {
+			t.Errorf("Matches(%q) error = %v, want ErrInvalidQuery", bad, err)
+		}
+	}
+}
+
+func TestOverview(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		wantType ValueType
+		wantSize int
+		wantOK   bool
+	}{
+		{"object", ` {"a":1,"b":{"c":[1,2,3]},"d":"x"} `, TypeObject, 3, true},
+		{"empty_object", `{}`, TypeObject, 0, true},
+		{"array", `[1, [2, 3], {"a": 4}, null]`, TypeArray, 4, true},
+		{"empty_array", `[ ]`, TypeArray, 0, true},
+		{"string", `"hello"`, TypeString, 5, true},
+		{"escaped_string", `"a\nbé"`, TypeString, 5, true},
+		{"number", `12.5`, TypeNumber, 0, true},
+		{"bool", `true`, TypeBoolean, 0, true},
+		{"null", `null`, TypeNull, 0, true},
+		{"invalid_object", `{"a":1,}`, TypeUndefined, 0, false},
+		{"invalid_array", `[1 2]`, TypeUndefined, 0, false},
+		{"unterminated_string", `"abc`, TypeUndefined, 0, false},
+		{"trailing", `[1] x`, TypeUndefined, 0, false},
+		{"empty", ``, TypeUndefined, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, size, ok := Overview([]byte(tt.json))
+			if typ != tt.wantType || size != tt.wantSize || ok != tt.wantOK {
+				t.Errorf("Overview(%q) = (%v, %d, %v), want (%v, %d, %v)",
+					tt.json, typ, size, ok, tt.wantType, tt.wantSize, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAssert(t *testing.T) {
+	rules := []Rule{
+		{Path: "id", Required: true, Type: TypeNumber},
+		{Path: "name", Required: true, Type: TypeString},
+		{Path: "email", Required: true},
+		{Path: "tags", Type: TypeArray},
+		{Path: "address.city", Required: true, Type: TypeString},
+	}
+
+	valid := []byte(`{"id":1,"name":"Ann","email":null,"address":{"city":"Paris"}}`)
+	if errs := Assert(valid, rules); len(errs) != 0 {
+		t.Errorf("Expected no violations, got %v", errs)
+	}
+
+	missing := []byte(`{"id":1,"email":"a@b.c","address":{}}`)
+	errs := Assert(missing, rules)
+	want := []string{
+		`path "name": required value is missing`,
+		`path "address.city": required value is missing`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d violations, got %v", len(want), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("violation %d = %q, want %q", i, err.Error(), want[i])
+		}
+	}
+
+	wrongType := []byte(`{"id":"1","name":"Ann","email":"x","tags":{},"address":{"city":75}}`)
+	errs = Assert(wrongType, rules)
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 violations, got %v", errs)
+	}
+	var re *RuleError
+	if !errors.As(errs[0], &re) || re.Path != "id" || re.Want != TypeNumber || re.Got != TypeString || re.Missing {
+		t.Errorf("Unexpected first violation: %#v", errs[0])
+	}
+	if errs[1].Error() != `path "tags": expected array, got object` {
+		t.Errorf("Unexpected second violation: %v", errs[1])
+	}
+	if errs[2].Error() != `path "address.city": expected string, got number` {
+		t.Errorf("Unexpected third violation: %v", errs[2])
+	}
+
+	if errs := Assert([]byte(`{"id":`), rules); len(errs) != 1 || errs[0] != ErrInvalidJSON {
+		t.Errorf("Expected ErrInvalidJSON, got %v", errs)
+	}
+}
+
+func TestRecursiveDescentFilter(t *testing.T) {
+	json := []byte(`{
+		"store": {
+			"book": [{"title": "a", "price": 8}, {"title": "b", "price": 12}],
+			"shelf": {"book": [{"title": "c", "price": 5}]}
+		},
+		"book": [{"title": "z", "price": 1}]
+	}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"store..book.#", `[2,1]`},
+		{"store..price", `[8,12,5]`},
+		{"store..book.#(price<10)#.title", `["a","c"]`},
+		{"store..book.#(price>10)#.title", `["b"]`},
+		{"store..book.#(price<10).title", `"a"`},
+		{"store..book.#(price<6).title", `"c"`},
+		{"store...book.#", `[2,1]`},
+		{"store..price|@sum", `25`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+
+	if Get(json, "store..missing").Exists() {
+		t.Error("expected no matches for store..missing")
+	}
+}
+
+func TestGetWithLimit(t *testing.T) {
+	json := []byte(`{
+		"id": 0,
+		"data": {
+			"id": 1,
+			"a": {"id": 2, "b": [{"id": 3}, {"id": 4, "c": {"id": 5}}]},
+			"d": [{"id": 6}, {"id": 7}, {"id": 8}]
+		},
+		"tags": ["x", "y", "z"],
+		"stats": {"a1": 1, "a2": 2, "a3": 3}
+	}`)
+
+	tests := []struct {
+		path  string
+		limit int
+		want  string
+	}{
+		// A recursive search collecting 8 ids stops after 5
+		{"data..id", 5, `[1,2,3,4,5]`},
+		{"data..id", 100, `[1,2,3,4,5,6,7,8]`},
+		{"data.a..id", 2, `[2,3]`},
+		{"data.d.#.id", 2, `[6,7]`},
+		{"data.d.*.id", 2, `[6,7]`},
+		{"data.d.#(id>6)#.id", 1, `[7]`},
+		{"data.d.#(id>6)#", 1, `[{"id": 7}]`},
+		{"stats.a*", 2, `[1,2]`},
+		{"data..id|@reverse", 3, `[3,2,1]`},
+		{"data..id,data.d.0.id", 2, `[[1,2],6]`},
+		// Values and arrays that are not collected are returned whole
+		{"tags", 1, `["x", "y", "z"]`},
+		{"data.a.b.1.c.id", 1, `5`},
+		{"data.d.#", 1, `3`},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.path, tt.limit), func(t *testing.T) {
+			result := GetWithLimit(json, tt.path, tt.limit)
+			if string(result.Raw) != tt.want {
+				t.Errorf("GetWithLimit(%q, %d) = %s, want %s", tt.path, tt.limit, result.Raw, tt.want)
+			}
+		})
+	}
+
+	// Without a limit, and for every path, the meaning is the same as Get
+	for _, path := range []string{"data..id", "..id", "..id|@reverse", "data.d.#.id", "tags", "data..id,id", "stats.a*"} {
+		if got, want := GetWithLimit(json, path, 0), Get(json, path); string(got.Raw) != string(want.Raw) {
+			t.Errorf("GetWithLimit(%q, 0) = %s, want %s", path, got.Raw, want.Raw)
+		}
+	}
+	if got, want := GetWithLimit(json, "..id", 3), Get(json, "..id"); string(got.Raw) != string(want.Raw) {
+		t.Errorf("GetWithLimit(..id, 3) = %s, want %s as with Get", got.Raw, want.Raw)
+	}
+
+	if GetWithLimit(json, "data..missing", 5).Exists() {
+		t.Error("expected no matches for data..missing")
+	}
+
+	// JSON Lines keep their meaning for a leading ..
+	lines := []byte("{\"id\":1}\n{\"id\":2}\n{\"id\":3}")
+	if got := GetWithLimit(lines, "..#.id", 2).Raw; string(got) != `[1,2]` {
+		t.Errorf("GetWithLimit on JSON Lines = %s, want [1,2]", got)
+	}
+}
+
+func TestEmptyPathSegments(t *testing.T) {
+	json := []byte(`{"a":{"b":1,"c":{"b":2},"d":[{"b":3}]}}`)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"direct", "a.b", `1`},
+		{"recursive", "a..b", `[1,2,3]`},
+		{"recursive_nested", "a.c..b", `[2]`},
+		{"repeated_dots", "a...b", `[1,2,3]`},
+		{"trailing_dot", "a.", `{"b":1,"c":{"b":2},"d":[{"b":3}]}`},
+		{"trailing_dot_leaf", "a.b.", `1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+
+	if Get(json, "a..x").Exists() {
+		t.Error("expected a..x to not exist")
+	}
+}
+
+// countingHandler tallies Scan events and stops at stopKey, if set
+type countingHandler struct {
+	objects, arrays, values int
+	keys                    []string
+	depth, maxDepth         int
+	stopKey                 string
+}
+
+func (h *countingHandler) OnObjectStart() error {
+	h.objects++
+	h.depth++
+	if h.depth > h.maxDepth {
+		h.maxDepth = h.depth
+	}
+	return nil
+}
+
+func (h *countingHandler) OnObjectEnd() error { h.depth--; return nil }
+
+func (h *countingHandler) OnArrayStart() error { h.arrays++; return nil }
+
+func (h *countingHandler) OnArrayEnd() error { return nil }
+
+func (h *countingHandler) OnKey(key []byte) error {
+	h.keys = append(h.keys, string(key))
+	if h.stopKey != "" && string(key) == h.stopKey {
+		return ErrStopScan
+	}
+	return nil
+}
+
+func (h *countingHandler) OnValue(Result) error { h.values++; return nil }
+
+func TestScan(t *testing.T) {
+	json := []byte(`{"a":{"b":[1,{"c":true},null]},"d\u0021":"x","e":[]}`)
+
+	h := &countingHandler{}
+	if err := Scan(json, h); err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if h.objects != 3 || h.arrays != 2 || h.values != 4 || h.maxDepth != 3 {
+		t.Errorf("objects=%d arrays=%d values=%d depth=%d", h.objects, h.arrays, h.values, h.maxDepth)
+	}
+	if got := strings.Join(h.keys, ","); got != "a,b,c,d!,e" {
+		t.Errorf("keys = %s", got)
+	}
+
+	stop := &countingHandler{stopKey: "c"}
+	if err := Scan(json, stop); err != nil {
+		t.Fatalf("Scan with ErrStopScan returned %v", err)
+	}
+	if len(stop.keys) != 3 {
+		t.Errorf("expected scan to stop after 3 keys, got %v", stop.keys)
+	}
+
+	for _, bad := range []string{`{"a":1,}`, `[1 2]`, `{"a":1} x`, ``} {
+		if err := Scan([]byte(bad), &countingHandler{}); !errors.Is(err, ErrInvalidJSON) {
+			t.Errorf("Scan(%q) = %v, want ErrInvalidJSON", bad, err)
+		}
+	}
+}
+
+func TestResultEqualValue(t *testing.T) {
+	json := []byte(`{"name":"nq","count":3,"ids":[1,2,3],"meta":{"ok":true},"none":null}`)
+
+	tests := []struct {
+		path string
+		v    interface{}
+		want bool
+	}{
+		{"name", "nq", true},
+		{"name", "NQ", false},
+		{"count", 3, true},
+		{"count", 3.0, true},
+		{"count", "3", false},
+		{"ids", []int{1, 2, 3}, true},
+		{"ids", []int{1, 2}, false},
+		{"meta", map[string]bool{"ok": true}, true},
+		{"none", nil, true},
+		{"missing", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := Get(json, tt.path).EqualValue(tt.v); got != tt.want {
+			t.Errorf("Get(%q).EqualValue(%#v) = %v, want %v", tt.path, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestWarmPathsAndClearPathCache(t *testing.T) {
+	json := []byte(`{"users":[{"name":"Ann","age":31},{"name":"Bo","age":25}]}`)
+	ClearPathCache()
+
+	if err := WarmPaths("users.0.name", "users.#(age>30).name"); err != nil {
+		t.Fatalf("WarmPaths error: %v", err)
+	}
+	if got := GetCached(json, "users.0.name").String(); got != "Ann" {
+		t.Errorf("GetCached = %q, want Ann", got)
+	}
+	if got := Get(json, "users.#(age>30).name").String(); got != "Ann" {
+		t.Errorf("Get on warmed query = %q, want Ann", got)
+	}
+	if stats := PathCacheStats(); stats.Hits != 1 || stats.Misses != 0 {
+		t.Errorf("stats after warm = %+v, want 1 hit", stats)
+	}
+
+	ClearPathCache()
+	if stats := PathCacheStats(); stats != (CacheStats{}) {
+		t.Errorf("stats after clear = %+v, want zero", stats)
+	}
+	GetCached(json, "users.0.name")
+	if stats := PathCacheStats(); stats.Misses != 1 {
+		t.Errorf("stats after clear = %+v, want 1 miss", stats)
+	}
+
+	// A warmed filter path runs its cached tokens; swapping in the tokens of
+	// another path shows GetCached does not tokenize it again
+	ClearPathCache()
+	if err := WarmPaths("users.#(age>30).name", "users.#.name|@reverse"); err != nil {
+		t.Fatalf("WarmPaths error: %v", err)
+	}
+	if _, ok := pathCache.Load("users.#.name|@reverse"); ok {
+		t.Error("WarmPaths cached a multipath that GetCached cannot use")
+	}
+	pathCache.Store("users.#(age>30).name", tokenizePath("users.#(age<30).name"))
+	if got := GetCached(json, "users.#(age>30).name").String(); got != "Bo" {
+		t.Errorf("GetCached on warmed filter = %q, want the cached tokens' Bo", got)
+	}
+	if stats := PathCacheStats(); stats.Hits != 1 || stats.Misses != 0 {
+		t.Errorf("stats after warmed filter = %+v, want 1 hit", stats)
+	}
+	ClearPathCache()
+
+	if err := WarmPaths(""); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("WarmPaths(\"\") = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestKeysAtDepth(t *testing.T) {
+	json := []byte(`{
+		"id": 1,
+		"user": {"name": "a", "tags": ["x"], "address": {"city": "c"}},
+		"orders": [{"sku": "s1", "qty": 1}, {"sku": "s2", "note": "n"}],
+		"a.b": {"name": "dotted"}
+	}`)
+
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{0, "id,user,orders,a.b"},
+		{1, "name,tags,address"},
+		{2, "city,sku,qty,note"},
+		{3, ""},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(KeysAtDepth(json, tt.depth), ","); got != tt.want {
+			t.Errorf("KeysAtDepth(%d) = %q, want %q", tt.depth, got, tt.want)
+		}
+	}
+
+	if keys := KeysAtDepth([]byte(`[1,2]`), 0); keys != nil {
+		t.Errorf("expected no keys for an array root, got %v", keys)
+	}
+}
+
+func TestToObjectModifier(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		path string
+		want string
+	}{
+		{"object_pairs", `{"pairs":[{"key":"a","value":1},{"key":"b","value":[2]}]}`, "pairs.@toObject", `{"a":1,"b":[2]}`},
+		{"array_pairs", `{"pairs":[["a",1],["b","x"]]}`, "pairs.@toObject", `{"a":1,"b":"x"}`},
+		{"duplicate_keys", `[["a",1],{"key":"b","value":2},["a",3]]`, "@toObject", `{"a":3,"b":2}`},
+		{"round_trip", `{"x":1,"y":true}`, "@entries|@toObject", `{"x":1,"y":true}`},
+		{"skips_malformed", `[["a"],1,["b",2,3],["c",4]]`, "@toObject", `{"c":4}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Get([]byte(tt.json), tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+
+	if Get([]byte(`{"a":1}`), "@toObject").Exists() {
+		t.Error("expected @toObject on an object to be undefined")
+	}
+}
+
+func TestGetAuto(t *testing.T) {
+	plain := []byte(`{"user":{"name":"Ann","tags":["a","b"]}}`)
+
+	var gz, zl bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(plain)
+	gw.Close()
+	zw := zlib.NewWriter(&zl)
+	zw.Write(plain)
+	zw.Close()
+
+	inputs := map[string][]byte{"plain": plain, "gzip": gz.Bytes(), "zlib": zl.Bytes()}
+	for name, data := range inputs {
+		t.Run(name, func(t *testing.T) {
+			result, err := GetAuto(data, "user.tags.1")
+			if err != nil {
+				t.Fatalf("GetAuto error: %v", err)
+			}
+			if result.String() != "b" {
+				t.Errorf("GetAuto = %q, want b", result.String())
+			}
+		})
+	}
+
+	truncated := gz.Bytes()[:gz.Len()/2]
+	if _, err := GetAuto(truncated, "user"); err == nil {
+		t.Error("expected an error for truncated gzip input")
+	}
+
+	size := int64(len(plain))
+	if result, err := GetAutoWithMaxSize(gz.Bytes(), "user.name", size); err != nil || result.String() != "Ann" {
+		t.Errorf("GetAutoWithMaxSize at the size limit = %q, %v", result.String(), err)
+	}
+	for name, data := range map[string][]byte{"gzip": gz.Bytes(), "zlib": zl.Bytes()} {
+		if _, err := GetAutoWithMaxSize(data, "user", size-1); err != ErrTooLarge {
+			t.Errorf("%s over the size limit: got %v, want ErrTooLarge", name, err)
+		}
+		if _, err := GetAutoWithMaxSize(data, "user", 0); err != nil {
+			t.Errorf("%s without a size limit: %v", name, err)
+		}
+	}
+	if _, err := GetAutoWithMaxSize(plain, "user", 1); err != nil {
+		t.Errorf("plain input is not subject to the size limit: %v", err)
+	}
+}
+
+func TestResultSummary(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`{"items": [`)
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "note": "line\nbreak"}`, i)
+	}
+	b.WriteString("]}")
+	large := Parse([]byte(b.String()))
+
+	got := large.Summary(40)
+	if len(got) != 40 || !strings.HasSuffix(got, "...") {
+		t.Errorf("Summary(40) = %q (len %d)", got, len(got))
+	}
+	if !strings.HasPrefix(got, `{"items":[{"id":0,"note":"line\nbreak`) || strings.Contains(got, "\n") {
+		t.Errorf("Summary(40) should be minified and single-line, got %q", got)
+	}
+
+	small := Get([]byte(`{"a": {"b": [1, 2]}}`), "a")
+	if got := small.Summary(40); got != `{"b":[1,2]}` {
+		t.Errorf("Summary on small value = %q", got)
+	}
+	if got := Get([]byte(`{"s":"héllo"}`), "s").Summary(6); got != `"h...` {
+		t.Errorf("Summary should cut at a rune boundary, got %q", got)
+	}
+	if got := Get([]byte(`{}`), "missing").Summary(10); got != "" {
+		t.Errorf("Summary on missing = %q, want empty", got)
+	}
+}
+
+func TestTryParseAndMustParse(t *testing.T) {
+	r, ok := TryParse([]byte(` {"a":[1,2]} `))
+	if !ok || r.Get("a.1").Int() != 2 {
+		t.Errorf("TryParse on valid input = %v, %v", r, ok)
+	}
+	for _, bad := range []string{`{"a":1,}`, `{"a":1} {}`, `NaN`, ``} {
+		if r, ok := TryParse([]byte(bad)); ok || r.Exists() {
+			t.Errorf("TryParse(%q) = %v, %v; want undefined, false", bad, r, ok)
+		}
+	}
+
+	if got := MustParse([]byte(`"x"`)).String(); got != "x" {
+		t.Errorf("MustParse = %q, want x", got)
+	}
+
+	defer func() {
+		if v := recover(); v != ErrInvalidJSON {
+			t.Errorf("MustParse on invalid input panicked with %v, want ErrInvalidJSON", v)
+		}
+	}()
+	MustParse([]byte(`{"a":`))
+	t.Error("MustParse on invalid input did not panic")
+}
+
+func TestDiffAdjacentModifier(t *testing.T) {
+	json := []byte(`{"readings":[1,3,6,10],"drops":[5,2.5,-1],"single":[7],"empty":[],"mixed":[1,"x",4]}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"readings.@diffAdjacent", `[2,3,4]`},
+		{"drops|@diffAdjacent", `[-2.5,-3.5]`},
+		{"single.@diffAdjacent", `[]`},
+		{"empty.@diffAdjacent", `[]`},
+		{"mixed.@diffAdjacent", `[3]`},
+		{"readings.@diffAdjacent|@sum", `9`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+
+	if Get(json, "readings.0.@diffAdjacent").Exists() {
+		t.Error("expected @diffAdjacent on a number to be undefined")
+	}
+}
+
+func TestResultNumberString(t *testing.T) {
+	json := []byte(`{"price":1.2300,"big":12345678901234567890123,"exp":-1.5E+10,"items":[0.10],"s":"1.0","n":null}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"price", "1.2300"},
+		{"big", "12345678901234567890123"},
+		{"exp", "-1.5E+10"},
+		{"items.0", "0.10"},
+		{"s", ""},
+		{"n", ""},
+		{"missing", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Get(json, tt.path).NumberString(); got != tt.want {
+			t.Errorf("Get(%q).NumberString() = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if got := Parse([]byte(" 42.00 ")).NumberString(); got != "42.00" {
+		t.Errorf("Parse root NumberString() = %q, want 42.00", got)
+	}
+}
+
+func TestGetWithOptions_CollapseStringWhitespace(t *testing.T) {
+	json := []byte(`{"text":"  quick\t\tbrown \n\n fox  jumps ","n":1}`)
+	opts := &GetOptions{CollapseStringWhitespace: true}
+
+	result := GetWithOptions(json, "text", opts)
+	if got := result.String(); got != " quick brown fox jumps " {
+		t.Errorf("String() = %q, want collapsed whitespace", got)
+	}
+	if string(result.Raw) != `"  quick\t\tbrown \n\n fox  jumps "` {
+		t.Errorf("Raw should be unchanged, got %s", result.Raw)
+	}
+
+	if got := GetWithOptions(json, "text", &GetOptions{}).String(); got != "  quick\t\tbrown \n\n fox  jumps " {
+		t.Errorf("default String() = %q, want original text", got)
+	}
+	if got := GetWithOptions(json, "n", opts).Int(); got != 1 {
+		t.Errorf("non-string result changed: %d", got)
+	}
+}
+
+func TestArraySliceStep(t *testing.T) {
+	json := []byte(`{"items":[0,1,2,3,4,5,6,7,8,9,10,11],"users":[{"name":"a"},{"name":"b"},{"name":"c"}]}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"items[2:5]", `[2,3,4]`},
+		{"items[0:10:2]", `[0,2,4,6,8]`},
+		{"items[1::4]", `[1,5,9]`},
+		{"items[-3:]", `[9,10,11]`},
+		{"items[::-1]", `[11,10,9,8,7,6,5,4,3,2,1,0]`},
+		{"items[10:0:-3]", `[10,7,4,1]`},
+		{"items[-1:-4:-1]", `[11,10,9]`},
+		{"items[5:2]", `[]`},
+		{"items[::0]", `[]`},
+		{"items[0:1]", `[0]`},
+		{"users[::2].#.name", `["a","c"]`},
+		{"items|@slice:0:10:3", `[0,3,6,9]`},
+		{"items[::2]", `[0,2,4,6,8,10]`},
+		{"items[:]", `[0,1,2,3,4,5,6,7,8,9,10,11]`},
+		{"items[:100:5]", `[0,5,10]`},
+		{"items[0:10:x]", ``},
+		{"items[1:2:3:4]", ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultChannel(t *testing.T) {
+	json := []byte(`{"items":[1,2,3,4,5],"obj":{"a":"x","b":"y"},"n":7}`)
+
+	var sum int64
+	count := 0
+	for v := range Get(json, "items").Channel(2) {
+		sum += v.Int()
+		count++
+	}
+	if count != 5 || sum != 15 {
+		t.Errorf("array channel yielded %d values summing to %d, want 5 and 15", count, sum)
+	}
+
+	var values []string
+	for v := range Get(json, "obj").Channel(0) {
+		values = append(values, v.String())
+	}
+	if strings.Join(values, ",") != "x,y" {
+		t.Errorf("object channel yielded %v, want [x y]", values)
+	}
+
+	if _, ok := <-Get(json, "n").Channel(1); ok {
+		t.Error("expected a closed channel for a scalar")
+	}
+}
+
+func TestGetLines(t *testing.T) {
+	ndjson := []byte("{\"id\":0}\n{\"id\":1}\n\n  \n{\"id\":2}\r\n{\"id\":3}\n{\"id\":4}\n")
+
+	lines := GetLines(ndjson, 1, 4)
+	if len(lines) != 3 {
+		t.Fatalf("GetLines(1, 4) returned %d results, want 3", len(lines))
+	}
+	for i, line := range lines {
+		if got := line.Get("id").Int(); got != int64(i+1) {
+			t.Errorf("line %d id = %d, want %d", i, got, i+1)
+		}
+	}
+	if got := Get(ndjson, "..2.id").Int(); got != lines[1].Get("id").Int() {
+		t.Errorf("GetLines index does not match ..N syntax: %d", got)
+	}
+
+	if got := len(GetLines(ndjson, 3, 100)); got != 2 {
+		t.Errorf("GetLines past the end returned %d results, want 2", got)
+	}
+	if got := GetLines(ndjson, 2, 2); got != nil {
+		t.Errorf("empty range returned %v", got)
+	}
+}
+
+func TestGetWithOptions_MergeDuplicateKeys(t *testing.T) {
+	json := []byte(`{"a":{"x":1,"n":{"p":1}},"b":1,"a":{"x":3,"y":2,"n":{"q":2}},"b":2,` +
+		`"c":1,"c":{"z":1},"d":{"z":1},"d":2,"d":{"w":3},"list":[{"k":{"u":1},"k":{"v":2}}],"e\u0078":{"s":1},"ex":{"t":2}}`)
+	opts := &GetOptions{MergeDuplicateKeys: true}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"a", `{"x":1,"n":{"p":1,"q":2},"y":2}`},
+		{"a.x", `1`},
+		{"a.y", `2`},
+		{"a.n.q", `2`},
+		{"b", `1`},
+		{"c", `1`},
+		{"c.z", ``},
+		{"d", `{"z":1,"w":3}`},
+		{"list.0.k", `{"u":1,"v":2}`},
+		{"list", `[{"k":{"u":1,"v":2}}]`},
+		{"list.#.k.v", `[2]`},
+		{"ex", `{"s":1,"t":2}`},
+		{"a.y,d.w", `[2,3]`},
+	}
+	for _, tt := range tests {
+		if got := GetWithOptions(json, tt.path, opts).Raw; string(got) != tt.want {
+			t.Errorf("GetWithOptions(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	// Without the option the first occurrence is returned
+	if got := Get(json, "a").Raw; string(got) != `{"x":1,"n":{"p":1}}` {
+		t.Errorf("Get(a) = %s, want the first occurrence", got)
+	}
+	if got := Get(json, "b").Raw; string(got) != `1` {
+		t.Errorf("Get(b) = %s, want the first occurrence", got)
+	}
+	if got := GetWithOptions(json, "a.y", &GetOptions{}); got.Exists() {
+		t.Errorf("a.y should not exist without MergeDuplicateKeys, got %s", got.Raw)
+	}
+
+	// Documents without duplicates are queried as-is
+	plain := []byte(`{"a": {"x": 1}}`)
+	if got := GetWithOptions(plain, "a", opts).Raw; string(got) != `{"x": 1}` {
+		t.Errorf("GetWithOptions on plain document = %s", got)
+	}
+}
+
+func TestResultIsEmpty(t *testing.T) {
+	json := []byte(`{"obj":{ },"arr":[],"str":"","nil":null,"zero":0,"f":false,"full":{"a":1},"list":[0],"s":" "}`)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"obj", true},
+		{"arr", true},
+		{"str", true},
+		{"nil", true},
+		{"missing", true},
+		{"zero", false},
+		{"f", false},
+		{"full", false},
+		{"list", false},
+		{"s", false},
+	}
+	for _, tt := range tests {
+		if got := Get(json, tt.path).IsEmpty(); got != tt.want {
+			t.Errorf("Get(%q).IsEmpty() = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if !Parse([]byte(" [ \n ] ")).IsEmpty() {
+		t.Error("expected a whitespace-only root array to be empty")
+	}
+}
+
+func TestFlattenKeysModifier(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		path string
+		want string
+	}{
+		{"two_levels", `{"a":{"b":1,"c":{"d":"x"}},"e":true}`, "@flattenKeys", `{"a.b":1,"a.c.d":"x","e":true}`},
+		{"dotted_key", `{"v1.2":{"ok":1},"a":{"b.c":2}}`, "@flattenKeys", `{"v1\\.2.ok":1,"a.b\\.c":2}`},
+		{"arrays_and_empty", `{"a":{"list":[{"x":1}],"none":{}}}`, "@flattenKeys", `{"a.list":[{"x":1}],"a.none":{}}`},
+		{"nested_path", `{"cfg":{"db":{"host":"h","port":5432}}}`, "cfg.@flattenKeys", `{"db.host":"h","db.port":5432}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Get([]byte(tt.json), tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+
+	flat := Get([]byte(`{"v1.2":{"ok":1}}`), "@flattenKeys")
+	if got := flat.Get(`v1\\\.2\.ok`); got.Int() != 1 {
+		t.Errorf("flattened key lookup = %s, want 1", got.Raw)
+	}
+}
+
+func TestTopBottomModifiers(t *testing.T) {
+	json := []byte(`{
+		"scores": [7, 42, 3, 19, 42, 8],
+		"players": [
+			{"name": "a", "score": 30},
+			{"name": "b", "score": 10},
+			{"name": "c"},
+			{"name": "d", "score": 20}
+		]
+	}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"scores.@top:3", `[42,42,19]`},
+		{"scores.@bottom:2", `[3,7]`},
+		{"scores|@top:10", `[42,42,19,8,7,3]`},
+		{"scores.@top:0", `[]`},
+		{"players.@bottom:2:score|#.name", `["b","d"]`},
+		{"players.@top:1:score|0.name", `"a"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := Get(json, tt.path)
+			if string(result.Raw) != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.path, result.Raw, tt.want)
+			}
+		})
+	}
+
+	if Get(json, "scores.@top:x").Exists() {
+		t.Error("expected @top with a non-numeric count to be undefined")
+	}
+}
+
+func TestValidator(t *testing.T) {
+	doc := `{"name":"a \"quoted\" é value","list":[1,-2.5e+3,0,true,null],"nested":{"x":[]} }`
+	feed := func(chunks ...string) (bool, error) {
+		var v Validator
+		for _, chunk := range chunks {
+			if _, err := v.Write([]byte(chunk)); err != nil {
+				return false, err
+			}
+		}
+		return v.Valid()
+	}
+
+	// Split the document at every offset, including inside strings,
+	// escapes and numbers
+	for i := 0; i <= len(doc); i++ {
+		if ok, err := feed(doc[:i], doc[i:]); !ok || err != nil {
+			t.Fatalf("split at %d: Valid() = %v, %v", i, ok, err)
+		}
+	}
+	if ok, err := feed(`{"s":"ab`, `c\`, `"d`, `"}`); !ok || err != nil {
+		t.Errorf("chunks inside a string: Valid() = %v, %v", ok, err)
+	}
+	if ok, err := feed(" 12", "3 "); !ok || err != nil {
+		t.Errorf("root number: Valid() = %v, %v", ok, err)
+	}
+
+	invalid := []string{`{"a":1,}`, `[1 2]`, `{"a" 1}`, `[}`, `{"a":tru}`, `01`, `1.`, `"\x"`, `{} {}`, `[1,`, ``}
+	for _, in := range invalid {
+		if ok, err := feed(in); ok || err == nil || Valid([]byte(in)) {
+			t.Errorf("Valid() on %q = %v, %v", in, ok, err)
+		}
+	}
+
+	var v Validator
+	if _, err := io.WriteString(&v, `[1,]`); err == nil {
+		t.Error("expected a syntax error from Write")
+	}
+	if n, err := v.Write([]byte(`2]`)); n != 0 || err == nil {
+		t.Errorf("Write after an error = %d, %v; want 0 and the error", n, err)
+	}
+}
+
+func TestNegativeArrayIndex(t *testing.T) {
+	data := []byte(`{"items":["a","b","c"],"users":[{"name":"Ann"},{"name":"Bob"}],"obj":{"-1":"key"}}`)
+	tests := []struct {
+		path   string
+		want   string
+		exists bool
+	}{
+		{"items.-1", "c", true},
+		{"items.-3", "a", true},
+		{"items[-2]", "b", true},
+		{"users.-1.name", "Bob", true},
+		{"users[-2].name", "Ann", true},
+		{"items.-4", "", false},
+		{"items.-10", "", false},
+		{"obj.-1", "key", true},
+	}
+	for _, tt := range tests {
+		for name, get := range map[string]func([]byte, string) Result{"Get": Get, "GetCached": GetCached} {
+			r := get(data, tt.path)
+			if r.Exists() != tt.exists || r.String() != tt.want {
+				t.Errorf("%s(%q) = %q (exists %v), want %q (exists %v)", name, tt.path, r.String(), r.Exists(), tt.want, tt.exists)
+			}
+		}
+	}
+
+	if r := Get([]byte(`[1,2,3]`), "-1"); r.Int() != 3 {
+		t.Errorf("root -1 = %s, want 3", r.Raw)
+	}
+}
+
+func TestGetRegex(t *testing.T) {
+	data := []byte(`{"user_1":{"name":"Ann"},"user_22":"Bob","user_x":1,"admin":{"user_3":"Cy"},"list":[{"user_4":"Di"}]}`)
+
+	got, err := GetRegex(data, `^user_\d+$`)
+	if err != nil {
+		t.Fatalf("GetRegex() error = %v", err)
+	}
+	var paths []string
+	for _, kr := range got {
+		paths = append(paths, kr.Path)
+	}
+	if strings.Join(paths, ",") != "user_1,user_22" {
+		t.Errorf("GetRegex() paths = %v", paths)
+	}
+	if got[1].Value.String() != "Bob" {
+		t.Errorf("user_22 = %q, want Bob", got[1].Value.String())
+	}
+
+	depthTests := []struct {
+		depth int
+		want  string
+	}{
+		{0, "user_1,user_22"},
+		{1, "user_1,user_22,admin.user_3"},
+		{-1, "user_1,user_22,admin.user_3,list.0.user_4"},
+	}
+	for _, tt := range depthTests {
+		got, err := GetRegexDepth(data, `^user_\d+$`, tt.depth)
+		if err != nil {
+			t.Fatalf("GetRegexDepth(%d) error = %v", tt.depth, err)
+		}
+		paths = paths[:0]
+		for _, kr := range got {
+			paths = append(paths, kr.Path)
+			if !Get(data, kr.Path).Exists() {
+				t.Errorf("path %q does not resolve", kr.Path)
+			}
+		}
+		if strings.Join(paths, ",") != tt.want {
+			t.Errorf("GetRegexDepth(%d) paths = %v, want %s", tt.depth, paths, tt.want)
+		}
+	}
+
+	if _, err := GetRegex(data, `(`); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("bad pattern error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestResultReader(t *testing.T) {
+	data := []byte(`{"user":{"name":"Ann","tags":["a","b"]},"n":1}`)
+	for _, path := range []string{"user", "user.tags", "n", "@this"} {
+		r := Get(data, path)
+		got, err := io.ReadAll(r.Reader())
+		if err != nil {
+			t.Fatalf("ReadAll(%q) error = %v", path, err)
+		}
+		if !bytes.Equal(got, r.Raw) {
+			t.Errorf("Reader(%q) = %s, want %s", path, got, r.Raw)
+		}
+	}
+
+	got, _ := io.ReadAll(Get(data, "missing").Reader())
+	if string(got) != "null" {
+		t.Errorf("Reader() on missing = %q, want null", got)
+	}
+}
+
+func TestQueryInOperator(t *testing.T) {
+	data := []byte(`{"items":[{"id":1,"status":"active"},{"id":2,"status":"done"},{"id":3,"status":"pending"},{"id":4,"status":"on hold"}],"nums":[1,2,3]}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`items.#(status in [active,pending])#.id`, `[1,3]`},
+		{`items.#(status in [active,pending]).id`, `1`},
+		{`items.#(status in ["on hold", done])#.id`, `[2,4]`},
+		{`items.#(id in [1.0,4])#.status`, `["active","on hold"]`},
+		{`nums.#(in [2,3])#`, `[2,3]`},
+		{`items.#(status in [archived])#.id`, ``},
+		{`items.#(status=="in [active]")#.id`, ``},
+	}
+	for _, tt := range tests {
+		if got := Get(data, tt.path).Raw; string(got) != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	r := Get(data, `items.1`)
+	if ok, err := r.Matches(`status in [done,active]`); !ok || err != nil {
+		t.Errorf("Matches(in) = %v, %v; want true", ok, err)
+	}
+}
+
+func TestGetBytes(t *testing.T) {
+	data := []byte(`{"a":{"b":[1, {"c" : "x y" } ,3]}, "s":"q\"", "n": 12.5 , "arr":[[1,2],[3]], "k.d":1, "e":{}}`)
+	paths := []string{
+		"a", "a.b", "a.b.1", "a.b.1.c", "s", "n", "arr.1.0", `k\.d`, "e",
+		"a.b.-1", "a.b.#", "arr.#.0", "missing", "a.b.9", "a.b.1.c.d",
+	}
+	for _, path := range paths {
+		raw, ok := GetBytes(data, path)
+		want := Get(data, path)
+		if ok != want.Exists() || !bytes.Equal(raw, want.Raw) {
+			t.Errorf("GetBytes(%q) = %q, %v; want %q, %v", path, raw, ok, want.Raw, want.Exists())
+		}
+	}
+
+	// Simple paths return a slice of the input rather than a copy
+	raw, _ := GetBytes(data, "a.b.1")
+	if idx := bytes.Index(data, raw); idx < 0 || &data[idx] != &raw[0] {
+		t.Error("GetBytes should alias the input for simple paths")
+	}
+}
+
+func TestResultHash(t *testing.T) {
+	equal := [][2]string{
+		{`{"a":1,"b":[true,null,"x"]}`, `{ "b" : [ true, null, "x" ], "a" : 1 }`},
+		{`{"n":1}`, `{"n":1.0}`},
+		{`{"n":100}`, `{"n":1e2}`},
+		{`{"o":{"x":1,"y":2}}`, `{"o":{"y":2,"x":1}}`},
+		{`"a\u0062"`, `"ab"`},
+	}
+	for _, pair := range equal {
+		if a, b := Parse([]byte(pair[0])).Hash(), Parse([]byte(pair[1])).Hash(); a != b {
+			t.Errorf("Hash(%s) = %d, Hash(%s) = %d; want equal", pair[0], a, pair[1], b)
+		}
+	}
+
+	different := [][2]string{
+		{`{"a":1}`, `{"a":2}`},
+		{`{"a":1}`, `{"b":1}`},
+		{`[1,2]`, `[2,1]`},
+		{`{"a":"1"}`, `{"a":1}`},
+		{`{"a":null}`, `{"a":false}`},
+		{`["ab","c"]`, `["a","bc"]`},
+		{`{"a":{"b":1}}`, `{"a":{"b":1},"c":1}`},
+		{`[]`, `{}`},
+	}
+	for _, pair := range different {
+		if Parse([]byte(pair[0])).Hash() == Parse([]byte(pair[1])).Hash() {
+			t.Errorf("Hash(%s) == Hash(%s); want different", pair[0], pair[1])
+		}
+	}
+
+	if h := Get([]byte(`{}`), "missing").Hash(); h != 0 {
+		t.Errorf("Hash() of missing = %d, want 0", h)
+	}
+}
+
+func TestSortModifierByKey(t *testing.T) {
+	data := []byte(`{"users":[{"n":"c","age":30},{"n":"a","age":9},{"n":"x"},{"n":"b","age":100}],"one":[{"age":1}]}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"users.@sort:age|#.n", `["a","c","b","x"]`},
+		{"users.@sort:age:desc|#.n", `["b","c","a","x"]`},
+		{"users|@sort:n|#.n", `["a","b","c","x"]`},
+		{"users|@sort:n:desc|#.n", `["x","c","b","a"]`},
+		{"users|@sort:missing|#.n", `["c","a","x","b"]`},
+		{"one|@sort:age", `[{"age":1}]`},
+	}
+	for _, tt := range tests {
+		if got := Get(data, tt.path).Raw; string(got) != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNodeCount(t *testing.T) {
+	tests := []struct {
+		json string
+		want int
+	}{
+		{`1`, 1},
+		{`"s"`, 1},
+		{`{}`, 1},
+		{`[]`, 1},
+		{`{"a":[1,2]}`, 4},
+		{`[null,true,{"x":{"y":"z"}},[[]]]`, 8},
+		{` {"name":"Ann","tags":["a","b"],"address":{"city":"X","zip":null}} `, 8},
+	}
+	for _, tt := range tests {
+		got, err := NodeCount([]byte(tt.json))
+		if err != nil || got != tt.want {
+			t.Errorf("NodeCount(%s) = %d, %v; want %d", tt.json, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{``, `{"a":}`, `[1,]`, `{} []`} {
+		if n, err := NodeCount([]byte(bad)); !errors.Is(err, ErrInvalidJSON) || n != 0 {
+			t.Errorf("NodeCount(%q) = %d, %v; want ErrInvalidJSON", bad, n, err)
+		}
+	}
+}
+
+func TestQueryCompoundConditions(t *testing.T) {
+	data := []byte(`{"items":[
+		{"n":"a","active":true,"score":40,"age":30},
+		{"n":"b","active":false,"score":50,"age":15},
+		{"n":"c","active":true,"score":20,"vip":true,"age":40}
+	]}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`items.#(active==true && score>=30)#.n`, `["a"]`},
+		{`items.#(active==true && score>=30).n`, `"a"`},
+		{`items.#(age<20 || vip==true)#.n`, `["b","c"]`},
+		{`items.#(age<20 || vip==true).n`, `"b"`},
+		{`items.#(active==true&&score>=30)#.n`, `["a"]`},
+		// Left to right: (a || b) && c, not a || (b && c)
+		{`items.#(n=="a" || n=="b" && active==false)#.n`, `["b"]`},
+		// || stops at the first true clause; && at the first false one
+		{`items.#(score>45 || nope==1)#.n`, `["b"]`},
+		{`items.#(nope==1 && score>0)#.n`, ``},
+		// Missing fields are false, including for !=
+		{`items.#(vip!=true || age>35)#.n`, `["c"]`},
+		{`items.#(n=="a||b" || n=="c")#.n`, `["c"]`},
+	}
+	for _, tt := range tests {
+		if got := Get(data, tt.path).Raw; string(got) != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestQueryUnion(t *testing.T) {
+	data := []byte(`{"items":[
+		{"id":1,"a":1,"b":2},
+		{"id":2,"a":1},
+		{"id":3,"b":2},
+		{"id":4,"s":"x|y"}
+	]}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		// Item 1 matches both queries but appears once
+		{`items.#(a==1)||#(b==2)#.id`, `[1,3]`},
+		{`items.#(b==2)||#(a==1)#.id`, `[1,2]`},
+		{`items.#(a==1)||#(b==2)#|@count`, `2`},
+		{`items.#(a==1)||#(b==2).id`, `[1]`},
+		{`items.#(a==1)||#(id==3)#.id`, `[1,3]`},
+		{`items.#(id==2)||#(b==2)#||#(id==4).id`, `[1,2,3,4]`},
+		{`items.#(id>2 || a==1)||#(s=="x|y")#.id`, `[1,4]`},
+		{`items.#(a==1)||#(nope==1)#.id`, `[1]`},
+		{`items.#(nope==1)||#(nope==2)#`, ``},
+		// Either leading form starts a union
+		{`items.#(a==1)#||#(b==2)#.id`, `[1,2,3]`},
+		{`items.#(a==1)#||#(b==2).id`, `[1,2]`},
+		{`items.#(a==1)#||#(b==2)#|@count`, `3`},
+		// A single | still pipes: the second query filters the matches of
+		// the first, or finds nothing in the single first match
+		{`items.#(a==1)#|#(b==2)#.id`, `[1]`},
+		{`items.#(a==1)#|#(b==2)#|@count`, `1`},
+		{`items.#(a==1)|#(b==2)#.id`, ``},
+		{`items.#(a==1 || b==2)#.id`, `[1,2,3]`},
+	}
+	for _, tt := range tests {
+		if got := Get(data, tt.path).Raw; string(got) != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	if got := Get(data, `items.#(a==1)||#(b==2)#`); !got.IsArray() || len(got.Array()) != 2 {
+		t.Errorf("union = %s, want 2 elements", got.Raw)
+	}
+}
+
+func TestResultTypedSlices(t *testing.T) {
+	data := []byte(`{"ints":[1,2,3],"floats":[1.5,-2,3e2],"strs":["a","b"],"mixed":[7,"8",true,null,"x",2.9,{"a":1}],"empty":[],"obj":{"a":1}}`)
+
+	if got := Get(data, "ints").IntSlice(); fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("ints IntSlice() = %v", got)
+	}
+	if got := Get(data, "floats").FloatSlice(); fmt.Sprint(got) != "[1.5 -2 300]" {
+		t.Errorf("floats FloatSlice() = %v", got)
+	}
+	if got := Get(data, "strs").StringSlice(); fmt.Sprint(got) != "[a b]" {
+		t.Errorf("strs StringSlice() = %v", got)
+	}
+
+	mixed := Get(data, "mixed")
+	if got := mixed.IntSlice(); fmt.Sprint(got) != "[7 8 1 0 0 2 0]" {
+		t.Errorf("mixed IntSlice() = %v", got)
+	}
+	if got := mixed.FloatSlice(); fmt.Sprint(got) != "[7 8 1 0 0 2.9 0]" {
+		t.Errorf("mixed FloatSlice() = %v", got)
+	}
+	if got := mixed.StringSlice(); strings.Join(got, "|") != `7|8|true|null|x|2.9|{"a":1}` {
+		t.Errorf("mixed StringSlice() = %q", got)
+	}
+
+	if got := Get(data, "empty").IntSlice(); got == nil || len(got) != 0 {
+		t.Errorf("empty IntSlice() = %#v, want empty non-nil", got)
+	}
+	if Get(data, "obj").IntSlice() != nil || Get(data, "missing").StringSlice() != nil {
+		t.Error("typed slices of non-arrays should be nil")
+	}
+}
+
+func TestTrimModifiers(t *testing.T) {
+	data := []byte(`{"name":"  Ann Lee \t\n","url":"/api/users/","n":5,"esc":" \"q\" "}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"name.@trim", `Ann Lee`},
+		{"name|@trim|@upper", `ANN LEE`},
+		{"url.@trimPrefix:/", `api/users/`},
+		{"url|@trimSuffix:/", `/api/users`},
+		{"url|@trimPrefix:/api|@trimSuffix:/", `/users`},
+		{"url|@trimPrefix:x", `/api/users/`},
+		{"esc.@trim", `"q"`},
+	}
+	for _, tt := range tests {
+		r := Get(data, tt.path)
+		if r.Type != TypeString || r.String() != tt.want || Parse(r.Raw).String() != tt.want {
+			t.Errorf("Get(%q) = %s, want %q", tt.path, r.Raw, tt.want)
+		}
+	}
+	if got := Get(data, "n.@trim").Raw; string(got) != `5` {
+		t.Errorf("@trim on a number = %s, want 5", got)
+	}
+}
+
+func TestGetSegments(t *testing.T) {
+	data := []byte(`{"users":[{"first.name":"Ann","tags":["a","b"]},{"first.name":"Bob"}],"0":{"1":"digits"},"a*b":{"#":1,"@this":2},"q\"k":"quote","":"empty"}`)
+	tests := []struct {
+		segments []string
+		want     string
+	}{
+		{[]string{"users", "0", "first.name"}, `"Ann"`},
+		{[]string{"users", "1", "first.name"}, `"Bob"`},
+		{[]string{"users", "-1", "first.name"}, `"Bob"`},
+		{[]string{"users", "0", "tags", "1"}, `"b"`},
+		{[]string{"0", "1"}, `"digits"`},
+		{[]string{"a*b", "#"}, `1`},
+		{[]string{"a*b", "@this"}, `2`},
+		{[]string{`q"k`}, `"quote"`},
+		{[]string{""}, `"empty"`},
+		{[]string{"users", "2"}, ``},
+		{[]string{"users", "first.name"}, ``},
+		{[]string{"users", "+1"}, ``},
+		{[]string{"first", "name"}, ``},
+		{[]string{"users", "0", "first.name", "x"}, ``},
+	}
+	for _, tt := range tests {
+		if got := GetSegments(data, tt.segments...).Raw; string(got) != tt.want {
+			t.Errorf("GetSegments(%q) = %s, want %s", tt.segments, got, tt.want)
+		}
+	}
+
+	if got := GetSegments(data); !got.IsObject() {
+		t.Errorf("GetSegments() with no segments = %s, want the document", got.Raw)
+	}
+}
+
+func TestResultForEachIndex(t *testing.T) {
+	arr := Get([]byte(`{"a":["x","y","z"]}`), "a")
+
+	var got []string
+	arr.ForEachIndex(func(i int, value Result) bool {
+		got = append(got, strconv.Itoa(i)+"="+value.String())
+		return true
+	})
+	if strings.Join(got, ",") != "0=x,1=y,2=z" {
+		t.Errorf("ForEachIndex visited %v", got)
+	}
+
+	// ForEach reports the same index as a number key
+	n := int64(0)
+	arr.ForEach(func(key, _ Result) bool {
+		if key.Type != TypeNumber || key.Int() != n {
+			t.Errorf("ForEach array key = %#v, want index %d", key, n)
+		}
+		n++
+		return true
+	})
+
+	calls := 0
+	arr.ForEachIndex(func(i int, _ Result) bool {
+		calls++
+		return i < 1
+	})
+	if calls != 2 {
+		t.Errorf("ForEachIndex made %d calls after returning false at index 1, want 2", calls)
+	}
+
+	for _, r := range []Result{Get([]byte(`{"a":1}`), "@this"), Parse([]byte(`"s"`)), {}} {
+		r.ForEachIndex(func(int, Result) bool {
+			t.Errorf("ForEachIndex called on %s", r.Raw)
+			return true
+		})
+	}
+}
+
+func TestDetectEncodingAndToUTF8(t *testing.T) {
+	doc := `{"name":"Zoë","emoji":"😀","n":[1,2]}`
+	runes := []rune(doc)
+
+	utf16le := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode(runes) {
+		utf16le = append(utf16le, byte(u), byte(u>>8))
+	}
+	var utf16be, utf32le, utf32be []byte
+	for _, u := range utf16.Encode(runes) {
+		utf16be = append(utf16be, byte(u>>8), byte(u))
+	}
+	for _, r := range runes {
+		utf32le = append(utf32le, byte(r), byte(r>>8), byte(r>>16), byte(r>>24))
+		utf32be = append(utf32be, byte(r>>24), byte(r>>16), byte(r>>8), byte(r))
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want Encoding
+	}{
+		{"utf8", []byte(doc), EncodingUTF8},
+		{"utf8_bom", append([]byte{0xEF, 0xBB, 0xBF}, doc...), EncodingUTF8},
+		{"utf16le_bom", utf16le, EncodingUTF16LE},
+		{"utf16le", utf16le[2:], EncodingUTF16LE},
+		{"utf16be", utf16be, EncodingUTF16BE},
+		{"utf32le", utf32le, EncodingUTF32LE},
+		{"utf32be_bom", append([]byte{0, 0, 0xFE, 0xFF}, utf32be...), EncodingUTF32BE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectEncoding(tt.data); got != tt.want {
+				t.Fatalf("DetectEncoding() = %v, want %v", got, tt.want)
+			}
+			out, err := ToUTF8(tt.data)
+			if err != nil || string(out) != doc {
+				t.Fatalf("ToUTF8() = %q, %v; want %q", out, err, doc)
+			}
+			if got := Get(out, "emoji").String(); got != "😀" {
+				t.Errorf("Get(emoji) = %q", got)
+			}
+		})
+	}
+
+	if _, err := ToUTF8(utf16le[:len(utf16le)-1]); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("ToUTF8(truncated) error = %v, want ErrInvalidJSON", err)
+	}
+	if EncodingUTF16LE.String() != "UTF-16LE" {
+		t.Errorf("String() = %q", EncodingUTF16LE.String())
+	}
+}
+
+func TestGetReader(t *testing.T) {
+	doc := `{"a":{"b":[1,{"c":"x\"}"}, [2]]},"d" : true , "e\u0066":-1.5e3, "g":[], "7":"seven"}`
+
+	paths := []string{
+		"a", "a.b.1", "a.b.1.c", "a.b.2", "d", "ef", ":7", "7",
+		"a.b.5", "g.0", "zz", "d.x",
+		// Paths the streaming walker cannot follow fall back to Get
+		"a.b.#", "a.b.-1", "a.b.#(c)", "a.b|@reverse",
+	}
+	for _, path := range paths {
+		got, err := GetReader(strings.NewReader(doc), path)
+		if err != nil {
+			t.Errorf("GetReader(%q) error = %v", path, err)
+			continue
+		}
+		want := Get([]byte(doc), path)
+		if string(got.Raw) != string(want.Raw) || got.Type != want.Type {
+			t.Errorf("GetReader(%q) = %s (%v), want %s (%v)", path, got.Raw, got.Type, want.Raw, want.Type)
+		}
+	}
+
+	// An early match never reads the rest of the stream
+	tail := iotest.ErrReader(errors.New("read past the match"))
+	got, err := GetReader(io.MultiReader(strings.NewReader(`{"id":7,"big":[`), tail), "id")
+	if err != nil || got.Int() != 7 {
+		t.Errorf("early match = %v, %v; want 7, nil", got.Raw, err)
+	}
+
+	// A miss drains the reader
+	rest := strings.NewReader(`{"a":1}`)
+	if got, err := GetReader(rest, "b"); err != nil || got.Exists() || rest.Len() != 0 {
+		t.Errorf("miss = %s, %v with %d bytes left; want no result, nil, 0", got.Raw, err, rest.Len())
+	}
+
+	for _, bad := range []string{`{"a":1`, `{"a" 1}`, `{"a":1;"b":2}`, `{"x":"open`, `{"b":[1,`, `{"b":[1}`} {
+		if _, err := GetReader(strings.NewReader(bad), "b.5"); err != ErrInvalidJSON {
+			t.Errorf("GetReader(%q) error = %v, want ErrInvalidJSON", bad, err)
+		}
+	}
+
+	readErr := errors.New("connection reset")
+	if _, err := GetReader(io.MultiReader(strings.NewReader(`{"a":`), iotest.ErrReader(readErr)), "b"); err != readErr {
+		t.Errorf("read error = %v, want %v", err, readErr)
+	}
+	if _, err := GetReader(iotest.ErrReader(readErr), "a.#"); err != readErr {
+		t.Errorf("fallback read error = %v, want %v", err, readErr)
+	}
+}
+
+func TestResultTimeFallbacks(t *testing.T) {
+	data := []byte(`{"rfc":"2023-10-17T10:30:00+02:00","date":"2023-10-17","epoch":1697539800,"frac":1697539800.25,"neg":-86400,"us":"10/17/2023","num":"1697539800","flag":true}`)
+
+	tests := []struct {
+		path string
+		want time.Time
+	}{
+		{"rfc", time.Date(2023, 10, 17, 8, 30, 0, 0, time.UTC)},
+		{"date", time.Date(2023, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"epoch", time.Date(2023, 10, 17, 10, 50, 0, 0, time.UTC)},
+		{"frac", time.Date(2023, 10, 17, 10, 50, 0, 250000000, time.UTC)},
+		{"neg", time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := Get(data, tt.path).Time()
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s Time() = %v, %v; want %v", tt.path, got, err, tt.want)
+		}
+	}
+	if got, _ := Get(data, "epoch").Time(); got.Location() != time.UTC {
+		t.Errorf("epoch Time() location = %v, want UTC", got.Location())
+	}
+
+	// Numeric strings and other layouts are not guessed at
+	for _, path := range []string{"us", "num", "flag", "missing"} {
+		if _, err := Get(data, path).Time(); err != ErrTypeConversion {
+			t.Errorf("%s Time() error = %v, want ErrTypeConversion", path, err)
+		}
+	}
+
+	got, err := Get(data, "us").TimeWithFormat("01/02/2006")
+	if err != nil || !got.Equal(time.Date(2023, 10, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("TimeWithFormat = %v, %v", got, err)
+	}
+	if _, err := Get(data, "date").TimeWithFormat("01/02/2006"); !errors.Is(err, ErrTypeConversion) {
+		t.Errorf("TimeWithFormat mismatch error = %v, want ErrTypeConversion", err)
+	}
+	if _, err := Get(data, "epoch").TimeWithFormat(time.RFC3339); err != ErrTypeConversion {
+		t.Errorf("TimeWithFormat on number error = %v, want ErrTypeConversion", err)
+	}
+}
+
+func TestResultIsInteger(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{`1`, true},
+		{`0`, true},
+		{`-0`, true},
+		{`-42`, true},
+		{`1e2`, true},
+		{`1E+2`, true},
+		{`1.50e2`, true},
+		{`1.0`, true},
+		{`2.000`, true},
+		{`0.0e-5`, true},
+		{`100e-2`, true},
+		{`123456789012345678901234567890`, true},
+		{`1.5`, false},
+		{`-0.1`, false},
+		{`1e-1`, false},
+		{`150e-3`, false},
+		{`1.0000000000000000001`, false},
+		{`"1"`, false},
+		{`true`, false},
+		{`null`, false},
+	}
+	for _, tt := range tests {
+		if got := Parse([]byte(tt.raw)).IsInteger(); got != tt.want {
+			t.Errorf("IsInteger(%s) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+	if Get([]byte(`{"a":1}`), "b").IsInteger() {
+		t.Error("IsInteger() of a missing value = true")
+	}
+}
+
+func TestResultStringN(t *testing.T) {
+	long := strings.Repeat(`h\u00e9llo \"w\" `, 100000)
+	data := []byte(`{"s":"` + long + `","n":12345.5,"arr":[1,"é",3],"t":true}`)
+	s := Get(data, "s")
+
+	if got := s.StringN(9); got != `héllo "w"` {
+		t.Errorf("StringN(9) = %q", got)
+	}
+	if got := s.StringN(1 << 30); got != s.String() {
+		t.Errorf("StringN(large) length %d, want %d", len(got), len(s.String()))
+	}
+	if allocs := testing.AllocsPerRun(100, func() { _ = s.StringN(1000) }); allocs != 0 {
+		t.Errorf("StringN on a string allocated %v times, want 0", allocs)
+	}
+
+	arr := Get(data, "arr")
+	if got := arr.StringN(6); got != `[1,"é"` {
+		t.Errorf("arr StringN(6) = %q", got)
+	}
+
+	tests := []struct {
+		path string
+		n    int
+		want string
+	}{
+		{"n", 3, "123"},
+		{"n", 100, "12345.5"},
+		{"t", 2, "tr"},
+		{"s", 0, ""},
+		{"s", -1, ""},
+		{"missing", 5, ""},
+	}
+	for _, tt := range tests {
+		if got := Get(data, tt.path).StringN(tt.n); got != tt.want {
+			t.Errorf("%s StringN(%d) = %q, want %q", tt.path, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestGroupModifierZip(t *testing.T) {
+	data := []byte(`{
+		"data":{"id":[1,2],"name":["a","b"]},
+		"uneven":{"id":[1,2,3],"name":["a","b"],"tag":"skip","q\"k":[true,false,null]},
+		"noarrays":{"a":1,"b":"x"},
+		"empty":{},
+		"emptyarr":{"id":[],"name":["a"]},
+		"users":[{"n":"a","city":"NYC"},{"n":"b","city":"LA"},{"n":"c","city":"NYC"}]
+	}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"data.@group", `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`},
+		{"data|@group", `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`},
+		{"uneven.@group", `[{"id":1,"name":"a","q\"k":true},{"id":2,"name":"b","q\"k":false}]`},
+		{"noarrays.@group", `[]`},
+		{"empty.@group", `[]`},
+		{"emptyarr.@group", `[]`},
+		{"data.@group|@count", `2`},
+		{"data.@group|1.name", `"b"`},
+		// With a field, arrays are still grouped by that field
+		{"users|@group:city|NYC", `[{"n":"a","city":"NYC"},{"n":"c","city":"NYC"}]`},
+	}
+	for _, tt := range tests {
+		got := Get(data, tt.path)
+		if string(got.Raw) != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.path, got.Raw, tt.want)
+		}
+	}
+	if got := Get(data, "uneven.@group"); got.Type != TypeArray || len(got.Array()) != 2 {
+		t.Errorf("uneven.@group = %v with %d rows, want an array of 2", got.Type, len(got.Array()))
+	}
+}
+
+func TestCSVCellModifier(t *testing.T) {
+	data := []byte(`{"plain":["a","b","c"],"nums":[1,2.5,true],"comma":["x,y","z"],"quote":["say \"hi\"","ok"],"line":["a\nb"],"empty":[],"s":"1,2","n":7,"null":null}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		// The default separator is a comma, so the joined cell is quoted
+		{"plain|@csvcell", `"a,b,c"`},
+		{"nums|@csvcell", `"1,2.5,true"`},
+		{"plain|@csvcell:;", `a;b;c`},
+		{"plain|@csvcell: / ", `a / b / c`},
+		{"comma|@csvcell", `"x,y,z"`},
+		{"comma|@csvcell:;", `"x,y;z"`},
+		{"quote|@csvcell:;", `"say ""hi"";ok"`},
+		{"line|@csvcell", "\"a\nb\""},
+		{"empty|@csvcell", ``},
+		{"s|@csvcell", `"1,2"`},
+		{"n|@csvcell", `7`},
+		{"null|@csvcell", ``},
+	}
+	for _, tt := range tests {
+		got := Get(data, tt.path)
+		if got.Type != TypeString || got.String() != tt.want {
+			t.Errorf("Get(%q) = %q (%v), want %q", tt.path, got.String(), got.Type, tt.want)
+		}
+		if Parse(got.Raw).String() != tt.want {
+			t.Errorf("Get(%q) raw %s does not decode to %q", tt.path, got.Raw, tt.want)
+		}
+	}
+	if Get(data, "missing|@csvcell").Exists() {
+		t.Error("@csvcell of a missing value should not exist")
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -82,6 +85,19 @@ type SetOptions struct {
 	// Context for cancelable operations
 	Context context.Context
 
+	// CreateArraysForNumericSegments creates arrays for missing numeric path
+	// segments, so "a.0.b" on {} yields {"a":[{"b":...}]}. Set and
+	// DefaultSetOptions enable it; leave it false, as in a zero SetOptions, to
+	// create objects keyed by the digits instead, as in {"a":{"0":{"b":...}}}.
+	// A ":0" segment is always an object key. An index above
+	// MaxCreatedArrayIndex fails with ErrArrayIndex rather than padding a huge
+	// array with nulls.
+	CreateArraysForNumericSegments bool
+
+	// RawStringAsJSON splices a string value into the document as raw JSON
+	// instead of storing it as a string literal. The string must be valid JSON.
+	RawStringAsJSON bool
+
 	// nextPath is the full path string for advanced operations (internal use)
 	nextPath string
 }
@@ -93,8 +109,14 @@ var DefaultSetOptions = SetOptions{
 	MergeArrays:    false,
 	MergeObjects:   false,
 	Context:        context.Background(),
+
+	CreateArraysForNumericSegments: true,
 }
 
+// MaxCreatedArrayIndex is the largest index for which Set creates a missing
+// array under CreateArraysForNumericSegments
+const MaxCreatedArrayIndex = 10000
+
 // SetPath represents a pre-compiled path for setting values
 type SetPath struct {
 	segments []setPathSegment
@@ -149,14 +171,17 @@ func (c *lruCache) Set(key string, value interface{}) {
 	c.items[key] = value
 }
 
+func (c *lruCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	clear(c.items)
+	c.order = c.order[:0]
+}
+
 // hashString creates a simple hash of a string
 func hashString(s string) uint64 {
-	var h uint64 = 14695981039346656037 // FNV offset basis
-	for i := 0; i < len(s); i++ {
-		h ^= uint64(s[i])
-		h *= 1099511628211 // FNV prime
-	}
-	return h
+	return fnv1a(fnvOffset64, s)
 }
 
 // Thread-safe caches for set operations
@@ -168,45 +193,87 @@ var (
 // This is the main entry point for most use cases.
 func Set(json []byte, path string, value interface{}) ([]byte, error) {
 	// Basic validation for common JSON errors
+	if err := checkPlaceholderValues(json); err != nil {
+		return nil, err
+	}
+
+	// Splice the value in with the byte-level fast paths where possible so
+	// untouched tokens are copied verbatim, then compact the output
+	result, err := SetWithOptions(json, path, value, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply compacting as natural side-effect
+	compacted := make([]byte, 0, len(result))
+	compacted = appendCompactBytes(compacted, result)
+	return compacted, nil
+}
+
+// checkPlaceholderValues rejects documents holding unquoted json or undefined
+// placeholders, a common templating mistake.
+func checkPlaceholderValues(json []byte) error {
 	if len(json) > 0 {
 		jsonStr := string(json)
 		if strings.Contains(jsonStr, ": json}") || strings.Contains(jsonStr, ": undefined}") ||
 			strings.Contains(jsonStr, ": json,") || strings.Contains(jsonStr, ": undefined,") {
-			return nil, errors.New("invalid JSON syntax")
+			return errors.New("invalid JSON syntax")
 		}
 	}
+	return nil
+}
 
-	// If key exists, use ReplaceInPlace for direct byte manipulation and compacting
-	if len(json) > 0 && path != "" && !strings.Contains(path, ".") &&
-		!strings.Contains(path, "[") && !strings.Contains(path, "?") && !strings.Contains(path, "*") {
-		// For simple single keys, check if they exist
-		keyStart, _, _ := findKeyValueRange(json, path)
-		if keyStart >= 0 {
-			// Key exists - use ReplaceInPlace + Optimistic for compacting
-			result, err := SetWithOptions(json, path, value, &SetOptions{
-				ReplaceInPlace: true,
-				Optimistic:     true,
-			})
-			if err != nil {
-				return nil, err
-			}
-			// Ensure result is compacted (natural side-effect)
-			compacted := make([]byte, 0, len(result))
-			compacted = appendCompactBytes(compacted, result)
-			return compacted, nil
+// SetAndGet sets value at path and returns the new document together with a
+// Result for the value just written, pointing into the new document.
+// An append path ending in "-1" resolves to the new last element.
+func SetAndGet(json []byte, path string, value interface{}) ([]byte, Result, error) {
+	result, err := Set(json, path, value)
+	if err != nil {
+		return json, Result{}, err
+	}
+
+	getPath := path
+	if parent, ok := cutAppendIndex(path); ok {
+		n, _ := ArrayLen(result, parent)
+		getPath = strconv.Itoa(n - 1)
+		if parent != "" {
+			getPath = parent + "." + getPath
 		}
 	}
+	return result, Get(result, getPath), nil
+}
 
-	// For new keys or complex paths, use default approach but compact output
-	result, err := SetWithOptions(json, path, value, nil)
-	if err != nil {
-		return nil, err
+// cutAppendIndex strips a trailing append index ("-1" or "[-1]") from path
+func cutAppendIndex(path string) (string, bool) {
+	switch {
+	case path == "-1":
+		return "", true
+	case strings.HasSuffix(path, ".-1"):
+		return path[:len(path)-3], true
+	case strings.HasSuffix(path, "[-1]"):
+		return path[:len(path)-4], true
 	}
+	return path, false
+}
 
-	// Apply compacting as natural side-effect
-	compacted := make([]byte, 0, len(result))
-	compacted = appendCompactBytes(compacted, result)
-	return compacted, nil
+// SetRaw splices rawValue into json at path without encoding it, so an
+// already serialized object is not turned into a string. The caller
+// guarantees rawValue is valid JSON; only an empty value is rejected, with
+// ErrInvalidJSON. Otherwise it behaves like Set, including the compacted
+// output and the creation of missing parents.
+func SetRaw(json []byte, path string, rawValue []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(rawValue)) == 0 {
+		return json, ErrInvalidJSON
+	}
+	return Set(json, path, rawJSON(rawValue))
+}
+
+// rawJSON marks a value that is already serialized JSON
+type rawJSON []byte
+
+// MarshalJSON returns r unchanged
+func (r rawJSON) MarshalJSON() ([]byte, error) {
+	return r, nil
 }
 
 // SetWithOptions sets a value with the specified options
@@ -229,6 +296,26 @@ func SetWithOptions(json []byte, path string, value interface{}, options *SetOpt
 		json = []byte("{}")
 	}
 
+	// Filter segments such as users.#(id==2).active are resolved to the
+	// index of the first matching element
+	path, err := resolveSetFilters(json, path)
+	if err != nil {
+		return json, err
+	}
+
+	if str, ok := value.(string); ok && opts.RawStringAsJSON {
+		if !Valid(stringToBytes(str)) {
+			return json, ErrInvalidJSON
+		}
+		value = []byte(str)
+	}
+
+	// Missing containers below a numeric segment are built up front so the
+	// array/object choice does not depend on which fast path runs
+	if created, ok, err := setCreatingContainers(json, path, value, opts); ok || err != nil {
+		return created, err
+	}
+
 	// Ultra-fast path optimization: prioritize byte-level operations for maximum performance
 	if isSimpleSetPath(path) && !opts.ReplaceInPlace && !opts.MergeObjects && !opts.MergeArrays {
 		if fast, ok, err := trySimpleFastPaths(json, path, value); err == nil && ok {
@@ -250,6 +337,138 @@ func SetWithOptions(json []byte, path string, value interface{}, options *SetOpt
 	return SetWithCompiledPath(json, compiledPath, value, &opts)
 }
 
+// resolveSetFilters replaces each #(condition) segment of path with the
+// index of the first array element matching it, so the rest of the set
+// engine only sees plain keys and indexes.
+func resolveSetFilters(json []byte, path string) (string, error) {
+	if !strings.Contains(path, "#(") {
+		return path, nil
+	}
+
+	parts := splitPathSegments(path)
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "#(") {
+			continue
+		}
+		if !strings.HasSuffix(part, ")") {
+			return path, ErrInvalidPath // #(...)# selects many elements
+		}
+
+		parent := Parse(json)
+		if i > 0 {
+			parent = Get(json, strings.Join(parts[:i], "."))
+		}
+		if parent.Type != TypeArray {
+			return path, ErrPathNotFound
+		}
+
+		idx, n := -1, 0
+		var matchErr error
+		parent.ForEach(func(_, elem Result) bool {
+			ok, err := elem.Matches(part)
+			if err != nil {
+				matchErr = err
+				return false
+			}
+			if ok {
+				idx = n
+				return false
+			}
+			n++
+			return true
+		})
+		if matchErr != nil {
+			return path, ErrInvalidPath
+		}
+		if idx < 0 {
+			return path, ErrPathNotFound
+		}
+		parts[i] = strconv.Itoa(idx)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// setCreatingContainers handles paths where more than the final segment is
+// missing and a segment below the existing part is numeric. The missing
+// containers are built as one value and set on the deepest existing parent.
+func setCreatingContainers(json []byte, path string, value interface{}, opts SetOptions) ([]byte, bool, error) {
+	if value == deletionMarkerValue || !isSimpleSetPath(path) || strings.Contains(path, "[") {
+		return nil, false, nil
+	}
+
+	parts := splitPath(path)
+	if len(parts) < 2 || !hasNumericSegment(parts[1:]) {
+		return nil, false, nil
+	}
+
+	// An existing value is replaced in place without looking further
+	if !opts.ReplaceInPlace && !opts.MergeObjects && !opts.MergeArrays {
+		if out, ok, err := setFastReplace(json, path, value); err == nil && ok {
+			return out, true, nil
+		}
+	}
+
+	// Walk down to the first missing segment
+	missing := 0
+	current := Parse(json)
+	for missing < len(parts) {
+		if current.Type != TypeObject && current.Type != TypeArray {
+			if missing == 0 {
+				return nil, false, nil
+			}
+			break
+		}
+		next := current.Get(parts[missing])
+		if !next.Exists() {
+			break
+		}
+		current = next
+		missing++
+	}
+	if missing >= len(parts)-1 || !hasNumericSegment(parts[missing:]) {
+		return nil, false, nil
+	}
+	if missing == 0 && !isRootObject(json) {
+		return nil, false, nil
+	}
+	if missing > 0 && current.Type != TypeObject && current.Type != TypeArray {
+		return nil, false, nil
+	}
+
+	// Wrap the value from the innermost segment outwards
+	nested := value
+	for j := len(parts) - 1; j > missing; j-- {
+		key := unescapePath(parts[j])
+		if hasColonPrefix(key) {
+			nested = map[string]interface{}{stripColonPrefix(key): nested}
+		} else if opts.CreateArraysForNumericSegments && isAllDigits(key) {
+			index := parseInt(key)
+			if index > MaxCreatedArrayIndex {
+				return json, true, ErrArrayIndex
+			}
+			arr := make([]interface{}, index+1)
+			arr[len(arr)-1] = nested
+			nested = arr
+		} else {
+			nested = map[string]interface{}{key: nested}
+		}
+	}
+
+	result, err := SetWithOptions(json, strings.Join(parts[:missing+1], "."), nested, &opts)
+	return result, true, err
+}
+
+// hasNumericSegment reports whether any path segment is all digits, with or
+// without the ":" object key prefix
+func hasNumericSegment(parts []string) bool {
+	for _, part := range parts {
+		if isAllDigits(strings.TrimPrefix(part, ":")) {
+			return true
+		}
+	}
+	return false
+}
+
 // SetString sets a value in a JSON string and returns the modified string
 func SetString(json string, path string, value interface{}) (string, error) {
 	result, err := Set([]byte(json), path, value)
@@ -259,6 +478,20 @@ func SetString(json string, path string, value interface{}) (string, error) {
 	return string(result), nil
 }
 
+// SetStringAppend appends suffix to the string at path, rewriting the string
+// token with the concatenation. It returns ErrPathNotFound if path does not
+// exist and ErrTypeMismatch if the value there is not a string.
+func SetStringAppend(json []byte, path string, suffix string) ([]byte, error) {
+	current := Get(json, path)
+	if !current.Exists() {
+		return json, ErrPathNotFound
+	}
+	if current.Type != TypeString {
+		return json, ErrTypeMismatch
+	}
+	return Set(json, path, current.Str+suffix)
+}
+
 // CompileSetPath compiles a path for repeated set operations
 func CompileSetPath(path string) (*SetPath, error) {
 	// Check cache first
@@ -329,6 +562,11 @@ func DeleteWithOptions(json []byte, path string, options *SetOptions) ([]byte, e
 		options = &DefaultSetOptions
 	}
 
+	// Array elements are cut out of the bytes, shifting the rest down
+	if out, ok, err := deleteArrayElement(json, path); ok {
+		return out, err
+	}
+
 	// Try ultra-fast delete paths first (compact JSON only to maintain formatting)
 	if !options.MergeObjects && !options.MergeArrays && !isLikelyPretty(json) {
 		// Try fast simple key deletion for compact JSON
@@ -356,6 +594,22 @@ func DeleteString(json string, path string) (string, error) {
 	return string(result), nil
 }
 
+// Pop removes the value at path and returns the new document together with
+// the removed value, so "queue.0" dequeues the head of an array. The Result
+// points into the original json, which is left unmodified. A missing path
+// returns ErrPathNotFound.
+func Pop(json []byte, path string) ([]byte, Result, error) {
+	removed := Get(json, path)
+	if !removed.Exists() {
+		return json, Result{}, ErrPathNotFound
+	}
+	result, err := Delete(json, path)
+	if err != nil {
+		return json, Result{}, err
+	}
+	return result, removed, nil
+}
+
 // Increment adds a delta to the numeric value at the specified path.
 // Returns error if the path doesn't exist or value is not a number.
 // This is equivalent to jq's `.path += delta`
@@ -397,16 +651,42 @@ func DecrementInt(json []byte, path string, delta int) ([]byte, error) {
 // DeleteMany removes values at multiple paths.
 // This is equivalent to jq's `delpaths([[path1], [path2], ...])`
 // Returns the modified JSON after all deletions.
+//
+// Every path refers to the original document, so "arr.0" and "arr.2" remove
+// the first and third elements. Values are removed from the end of the
+// document backwards, since deleting a later value never moves an earlier
+// one; a value named by several paths is removed once. Paths whose value
+// cannot be located, such as wildcards, go last in the order given.
 func DeleteMany(json []byte, paths ...string) ([]byte, error) {
 	if len(paths) == 0 {
 		return json, nil
 	}
 
+	type located struct {
+		path   string
+		offset int
+	}
+	ordered := make([]located, len(paths))
+	for i, path := range paths {
+		ordered[i] = located{path: path, offset: -1}
+		if r := Get(json, path); r.Exists() {
+			if off, ok := rawOffset(json, r.Raw); ok {
+				ordered[i].offset = off
+			}
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].offset > ordered[j].offset
+	})
+
 	result := json
 	var err error
 
-	for _, path := range paths {
-		result, err = Delete(result, path)
+	for i, item := range ordered {
+		if i > 0 && item.offset >= 0 && item.offset == ordered[i-1].offset {
+			continue // the same value named twice
+		}
+		result, err = Delete(result, item.path)
 		if err != nil {
 			// Skip paths that don't exist or had no change (like jq does)
 			if err == ErrPathNotFound || err == ErrNoChange {
@@ -423,6 +703,11 @@ func DeleteMany(json []byte, paths ...string) ([]byte, error) {
 // Arguments must be provided as path, value pairs.
 // Returns error if odd number of arguments is provided.
 //
+// Pairs are applied in the order given, so when one path is a prefix of
+// another the later pair writes into, or replaces, what the earlier one set.
+// The document is validated and compacted once for the whole batch rather
+// than once per pair as repeated Set calls would.
+//
 // Example:
 //
 //	result, _ := nqjson.SetMany(json,
@@ -440,6 +725,10 @@ func SetMany(json []byte, pathValues ...interface{}) ([]byte, error) {
 		return json, errors.New("SetMany requires path-value pairs (even number of arguments)")
 	}
 
+	if err := checkPlaceholderValues(json); err != nil {
+		return json, err
+	}
+
 	result := json
 	var err error
 
@@ -452,13 +741,13 @@ func SetMany(json []byte, pathValues ...interface{}) ([]byte, error) {
 
 		value := pathValues[i+1]
 
-		result, err = Set(result, path, value)
+		result, err = SetWithOptions(result, path, value, nil)
 		if err != nil {
 			return json, err
 		}
 	}
 
-	return result, nil
+	return appendCompactBytes(make([]byte, 0, len(result)), result), nil
 }
 
 // SetManyString is like SetMany but works with string JSON
@@ -470,6 +759,26 @@ func SetManyString(json string, pathValues ...interface{}) (string, error) {
 	return string(result), nil
 }
 
+// Set returns a copy of r with value set at path, relative to r. The
+// receiver is left unchanged. An undefined result is treated as an empty
+// object; scalar results cannot be modified and yield ErrTypeMismatch.
+//
+// Example:
+//
+//	user := nqjson.Get(json, "user")
+//	user, _ = user.Set("address.city", "Paris")
+func (r Result) Set(path string, value interface{}) (Result, error) {
+	if r.Exists() && r.Type != TypeObject && r.Type != TypeArray {
+		return r, ErrTypeMismatch
+	}
+
+	updated, err := Set(r.Raw, path, value)
+	if err != nil {
+		return r, err
+	}
+	return Parse(updated), nil
+}
+
 // isSimpleSetPath checks if a path can be processed without compilation
 func isSimpleSetPath(path string) bool {
 	// Path shouldn't be empty
@@ -612,7 +921,7 @@ func processPathSegment(window []byte, part string, baseOffset int, isLast bool)
 			// find object key value
 			s, e := getObjectValueRange(window, base)
 			if s < 0 {
-				return nil, 0, 0, 0, errors.New("key not found")
+				return nil, 0, 0, 0, ErrPathNotFound
 			}
 			baseOffset += s
 			window = window[s:e]
@@ -624,7 +933,7 @@ func processPathSegment(window []byte, part string, baseOffset int, isLast bool)
 			return nil, 0, 0, 0, err
 		}
 		if window == nil {
-			return nil, 0, 0, 0, errors.New("array index not found")
+			return nil, 0, 0, 0, ErrPathNotFound
 		}
 
 		if isLast {
@@ -640,7 +949,7 @@ func processPathSegment(window []byte, part string, baseOffset int, isLast bool)
 		idx, _ := strconv.Atoi(part)
 		s, e := getArrayElementRange(window, idx)
 		if s < 0 {
-			return nil, 0, 0, 0, errors.New("array index not found")
+			return nil, 0, 0, 0, ErrPathNotFound
 		}
 		baseOffset += s
 		window = window[s:e]
@@ -654,7 +963,7 @@ func processPathSegment(window []byte, part string, baseOffset int, isLast bool)
 	// Simple key
 	s, e := getObjectValueRange(window, part)
 	if s < 0 {
-		return nil, 0, 0, 0, errors.New("key not found")
+		return nil, 0, 0, 0, ErrPathNotFound
 	}
 	baseOffset += s
 	window = window[s:e]
@@ -2106,6 +2415,66 @@ func deleteFastPath(data []byte, path string) ([]byte, bool) {
 	return finalResult, true
 }
 
+// deleteArrayElement removes the array element at a path of plain keys ending
+// in an index, such as "items.1" or "items.-1", together with one adjacent
+// comma so the following elements shift down and the formatting is kept.
+// It reports false for paths it does not handle, and ErrNoChange when the
+// array exists but has no such element.
+func deleteArrayElement(data []byte, path string) ([]byte, bool, error) {
+	if strings.ContainsAny(path, "*?#@|(){}[]") {
+		return nil, false, nil
+	}
+	parts := splitPath(path)
+	last := parts[len(parts)-1]
+	index, err := strconv.Atoi(last)
+	if err != nil || !isNumericIndex(last) {
+		return nil, false, nil
+	}
+	// Incomplete or malformed documents go through the validated path
+	if !Valid(data) {
+		return nil, false, nil
+	}
+
+	parent := Parse(data)
+	if len(parts) > 1 {
+		parent = Get(data, path[:len(path)-len(last)-1])
+	}
+	arrStart, ok := rawOffset(data, parent.Raw)
+	if !ok || parent.Type != TypeArray || len(parent.Raw) < 2 || parent.Raw[len(parent.Raw)-1] != ']' {
+		return nil, false, nil
+	}
+	elem, _ := processIndexToken(parent, pathToken{kind: tokenIndex, num: index})
+	start, ok := rawOffset(data, elem.Raw)
+	if !ok {
+		return data, true, ErrNoChange
+	}
+	end := start + len(elem.Raw)
+
+	if next := skipSpaces(data, end); next < len(data) && data[next] == ',' {
+		// Take the comma after the element and the space before the next one
+		end = skipSpaces(data, next+1)
+	} else if prev := skipSpacesBack(data, start); prev > 0 && data[prev-1] == ',' {
+		// The last element takes the comma before it instead
+		start = prev - 1
+	} else {
+		// The only element: leave an empty array
+		start, end = arrStart+1, arrStart+len(parent.Raw)-1
+	}
+
+	out := make([]byte, 0, len(data)-(end-start))
+	out = append(out, data[:start]...)
+	return append(out, data[end:]...), true, nil
+}
+
+// skipSpacesBack returns the position just after the last non-space byte
+// before pos.
+func skipSpacesBack(data []byte, pos int) int {
+	for pos > 0 && data[pos-1] <= ' ' {
+		pos--
+	}
+	return pos
+}
+
 // deleteFastSimpleKey handles deletion of top-level keys using direct byte manipulation
 func deleteFastSimpleKey(data []byte, key string) (result []byte, changed bool) {
 	start, ok := findDeletionObjectStart(data)
@@ -2297,23 +2666,6 @@ func fastGetObjectValue(obj []byte, key string) []byte {
 }
 
 // fastEncodeJSONValue encodes basic Go values to JSON without full marshal when possible
-// tryParseStringAsJSON attempts to parse a string as JSON if it looks like JSON
-func tryParseStringAsJSON(val string) ([]byte, bool) {
-	// Check for JSON objects, arrays, or strings
-	if (strings.HasPrefix(val, "{") && strings.HasSuffix(val, "}")) ||
-		(strings.HasPrefix(val, "[") && strings.HasSuffix(val, "]")) ||
-		(strings.HasPrefix(val, "\"") && strings.HasSuffix(val, "\"")) {
-		var jsonVal interface{}
-		if err := json.Unmarshal([]byte(val), &jsonVal); err == nil {
-			// It's valid JSON, marshal it directly
-			if result, err := json.Marshal(jsonVal); err == nil {
-				return result, true
-			}
-		}
-	}
-	return nil, false
-}
-
 // handleByteSliceEncoding handles encoding of byte slices as JSON
 func handleByteSliceEncoding(val []byte) ([]byte, error) {
 	// Assume raw JSON if parsable; else treat as string
@@ -2334,28 +2686,50 @@ func encodeNumericValue(v interface{}) ([]byte, bool) {
 	case uint64:
 		return []byte(strconv.FormatUint(val, 10)), true
 	case float64:
-		// Default formatting similar to json.Marshal
-		return []byte(strconv.FormatFloat(val, 'f', -1, 64)), true
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			// Not representable in JSON; let json.Marshal report the error
+			return nil, false
+		}
+		return appendJSONFloat(nil, val), true
 	default:
 		return nil, false
 	}
 }
 
+// appendJSONFloat appends the shortest representation of f that round-trips,
+// using exponent form only for very small or very large magnitudes (the same
+// cutoffs as encoding/json).
+func appendJSONFloat(dst []byte, f float64) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
 func fastEncodeJSONValue(v interface{}) ([]byte, error) {
 	switch val := v.(type) {
 	case nil:
 		return []byte("null"), nil
 	case string:
-		// Try to parse as JSON first for strings that look like JSON
-		if result, isJSON := tryParseStringAsJSON(val); isJSON {
-			return result, nil
-		}
 		return encodeJSONString(val), nil
 	case bool:
 		if val {
 			return []byte("true"), nil
 		}
 		return []byte("false"), nil
+	case rawJSON:
+		return val, nil
 	case []byte:
 		return handleByteSliceEncoding(val)
 	default:
@@ -3070,7 +3444,7 @@ func handlePathValueSetting(ctx *SetPathNavigationContext, value interface{}, op
 func setValueWithPath(json []byte, path *SetPath, value interface{}, options *SetOptions) ([]byte, bool, error) {
 	// Parse the JSON into a generic structure
 	var data interface{}
-	if err := JSON.Unmarshal(json, &data); err != nil {
+	if err := unmarshalUseNumber(json, &data); err != nil {
 		return nil, false, ErrInvalidJSON
 	}
 
@@ -3277,6 +3651,12 @@ func convertToJSONValue(value interface{}) (interface{}, error) {
 		return v, nil
 	case float64, int, int64, uint64, bool:
 		return v, nil
+	case rawJSON:
+		var jsonVal interface{}
+		if err := JSON.Unmarshal(v, &jsonVal); err != nil {
+			return nil, ErrInvalidJSON
+		}
+		return jsonVal, nil
 	case []byte:
 		// Try to parse as JSON first
 		var jsonVal interface{}
@@ -3546,6 +3926,21 @@ var JSON = struct {
 	Unmarshal:     json.Unmarshal,
 }
 
+// unmarshalUseNumber is json.Unmarshal with numbers decoded as json.Number.
+// The Set and Delete fallbacks that rebuild the whole document use it, so
+// untouched numbers such as 1.2300 or 1e5 keep their original text.
+func unmarshalUseNumber(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrInvalidJSON
+	}
+	return nil
+}
+
 // PathContext holds the context of a path navigation operation
 type PathContext struct {
 	current        *interface{}
@@ -3970,7 +4365,7 @@ func handleDirectArrayDeletion(json []byte, path string, arr []interface{}, last
 	// Now we need to replace this array in the data structure
 	// We'll manually navigate to find where this array is stored
 	var data interface{}
-	if err := JSON.Unmarshal(json, &data); err != nil {
+	if err := unmarshalUseNumber(json, &data); err != nil {
 		return nil, ErrInvalidJSON
 	}
 
@@ -4034,7 +4429,7 @@ func handleParentContainerDeletion(parent interface{}, lastKey string, lastIndex
 func setSimplePath(json []byte, path string, value interface{}, options SetOptions) ([]byte, error) {
 	// Parse the JSON into a generic structure
 	var data interface{}
-	if err := JSON.Unmarshal(json, &data); err != nil {
+	if err := unmarshalUseNumber(json, &data); err != nil {
 		return json, ErrInvalidJSON
 	}
 
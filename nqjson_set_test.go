@@ -1,6 +1,8 @@
 package nqjson
 
 import (
+	"bytes"
+	"math"
 	"strconv"
 	"strings"
 	"testing"
@@ -941,6 +943,110 @@ func TestDelete_Operations(t *testing.T) {
 	}
 }
 
+func TestDelete_ArrayElementShifts(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		path   string
+		want   string
+		length int
+	}{
+		{"first", `{"arr":[10,20,30]}`, "arr.0", `{"arr":[20,30]}`, 2},
+		{"middle", `{"arr":[10,20,30]}`, "arr.1", `{"arr":[10,30]}`, 2},
+		{"last", `{"arr":[10,20,30]}`, "arr.2", `{"arr":[10,20]}`, 2},
+		{"negative", `{"arr":[10,20,30]}`, "arr.-1", `{"arr":[10,20]}`, 2},
+		{"only", `{"z":1,"arr":[10]}`, "arr.0", `{"z":1,"arr":[]}`, 0},
+		{"root", `[10, 20, 30]`, "1", `[10, 30]`, 2},
+		{"nested", `{"a":[[1,2],[3,4]]}`, "a.0.1", `{"a":[[1],[3,4]]}`, 1},
+		{"in object in array", `{"a":[{"b":[1,2]}]}`, "a.0.b.0", `{"a":[{"b":[2]}]}`, 1},
+		{"pretty middle", "{\n  \"arr\": [\n    1,\n    2,\n    3\n  ]\n}", "arr.1", "{\n  \"arr\": [\n    1,\n    3\n  ]\n}", 2},
+		{"pretty last", "{\n  \"arr\": [\n    1,\n    2\n  ]\n}", "arr.1", "{\n  \"arr\": [\n    1\n  ]\n}", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Delete([]byte(tt.json), tt.path)
+			if err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("Delete(%q) = %s, want %s", tt.path, result, tt.want)
+			}
+			parent := Parse(result)
+			if i := strings.LastIndex(tt.path, "."); i >= 0 {
+				parent = Get(result, tt.path[:i])
+			}
+			if n := len(parent.Array()); n != tt.length {
+				t.Errorf("Delete(%q) left %d elements, want %d", tt.path, n, tt.length)
+			}
+		})
+	}
+
+	// Out of range indexes leave the array alone rather than padding it
+	json := []byte(`{"arr":[10,20,30]}`)
+	for _, path := range []string{"arr.3", "arr.-4"} {
+		result, err := Delete(json, path)
+		if err != ErrNoChange || string(result) != string(json) {
+			t.Errorf("Delete(%q) = %s, %v; want unchanged, ErrNoChange", path, result, err)
+		}
+	}
+
+	// Incomplete documents are rejected, not spliced
+	for _, doc := range []string{`{"a":[1,2`, `{"a":[1,2]`, `[1,2`} {
+		path := "a.1"
+		if doc[0] == '[' {
+			path = "1"
+		}
+		if result, err := Delete([]byte(doc), path); err == nil {
+			t.Errorf("Delete(%s, %q) = %s, want an error", doc, path, result)
+		}
+	}
+}
+
+func TestPop(t *testing.T) {
+	json := []byte(`{"queue":[{"id":1},{"id":2},{"id":3}],"meta":{"owner":"ann","size":3}}`)
+	original := string(json)
+
+	doc, head, err := Pop(json, "queue.0")
+	if err != nil {
+		t.Fatalf("Pop(queue.0) error: %v", err)
+	}
+	if string(head.Raw) != `{"id":1}` || head.Get("id").Int() != 1 {
+		t.Errorf("Pop(queue.0) removed %s, want {\"id\":1}", head.Raw)
+	}
+	if want := `{"queue":[{"id":2},{"id":3}],"meta":{"owner":"ann","size":3}}`; string(doc) != want {
+		t.Errorf("Pop(queue.0) doc = %s, want %s", doc, want)
+	}
+
+	doc, owner, err := Pop(doc, "meta.owner")
+	if err != nil {
+		t.Fatalf("Pop(meta.owner) error: %v", err)
+	}
+	if owner.String() != "ann" {
+		t.Errorf("Pop(meta.owner) removed %q, want ann", owner.String())
+	}
+	if want := `{"queue":[{"id":2},{"id":3}],"meta":{"size":3}}`; string(doc) != want {
+		t.Errorf("Pop(meta.owner) doc = %s, want %s", doc, want)
+	}
+
+	// Draining the queue leaves an empty array
+	for want := int64(2); want <= 3; want++ {
+		var item Result
+		if doc, item, err = Pop(doc, "queue.0"); err != nil || item.Get("id").Int() != want {
+			t.Fatalf("Pop(queue.0) = %s, %v; want id %d", item.Raw, err, want)
+		}
+	}
+	if got := Get(doc, "queue").Raw; string(got) != `[]` {
+		t.Errorf("drained queue = %s, want []", got)
+	}
+
+	if same, r, err := Pop(doc, "queue.0"); err != ErrPathNotFound || r.Exists() || string(same) != string(doc) {
+		t.Errorf("Pop(empty queue) = %s, %s, %v; want unchanged, none, ErrPathNotFound", same, r.Raw, err)
+	}
+	if string(json) != original {
+		t.Errorf("Pop modified its input: %s", json)
+	}
+}
+
 // TestDeleteString_Operations tests DeleteString function using table-driven tests
 func TestDeleteString_Operations(t *testing.T) {
 	tests := []struct {
@@ -1648,6 +1754,20 @@ func TestEscapeSequences_Set(t *testing.T) {
 			value:    `"Interstellar"`,
 			expected: `{"fav.movie":"Interstellar"}`,
 		},
+		{
+			name:     "escaped_asterisk_in_key_set",
+			json:     `{"*":1,"a":2}`,
+			path:     `\*`,
+			value:    `9`,
+			expected: `{"*":9,"a":2}`,
+		},
+		{
+			name:     "escaped_hash_in_key_set",
+			json:     `{"stats":{"#":1,"count":2}}`,
+			path:     `stats.\#`,
+			value:    `9`,
+			expected: `{"stats":{"#":9,"count":2}}`,
+		},
 		{
 			name:     "escaped_colon_in_key_set",
 			json:     `{"user:name":"John"}`,
@@ -1911,6 +2031,50 @@ func TestSetHelpers_DeleteMany(t *testing.T) {
 	}
 }
 
+func TestSetHelpers_DeleteManyOriginalPaths(t *testing.T) {
+	json := []byte(`{"id":7,"user":{"name":"Ann","email":"a@x","age":30},"tags":["a","b","c","d"],"meta":{"v":1}}`)
+
+	result, err := DeleteMany(json, "id", "user.email", "meta")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := `{"user":{"name":"Ann","age":30},"tags":["a","b","c","d"]}`; string(result) != want {
+		t.Errorf("DeleteMany = %s, want %s", result, want)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		// Indexes refer to the original array whatever the order given
+		{"indexes", []string{"tags.0", "tags.2"}, `["b","d"]`},
+		{"indexes reversed", []string{"tags.2", "tags.0"}, `["b","d"]`},
+		{"negative index", []string{"tags.0", "tags.-1"}, `["b","c"]`},
+		// A child listed before its parent is fine either way
+		{"parent and child", []string{"user", "user.name"}, ``},
+		{"duplicates", []string{"tags.1", "tags.1"}, `["a","c","d"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DeleteMany(json, tt.paths...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			path := "tags"
+			if strings.HasPrefix(tt.paths[0], "user") {
+				path = "user"
+			}
+			if got := Get(result, path).Raw; string(got) != tt.want {
+				t.Errorf("DeleteMany(%q) %s = %s, want %s", tt.paths, path, got, tt.want)
+			}
+			if !Get(result, "id").Exists() {
+				t.Errorf("DeleteMany(%q) removed id: %s", tt.paths, result)
+			}
+		})
+	}
+}
+
 func TestSetHelpers_SetMany(t *testing.T) {
 	t.Run("SetMany_bytes", func(t *testing.T) {
 		json := []byte(`{"user": {"name": "Alice"}}`)
@@ -1959,3 +2123,474 @@ func TestSetHelpers_SetMany(t *testing.T) {
 		}
 	})
 }
+
+func TestSetHelpers_SetManyOrderAndEquivalence(t *testing.T) {
+	json := []byte(`{"service": {"name": "old"}, "tags": ["x"]}`)
+	pairs := []interface{}{
+		"service.port", 8080,
+		"service", map[string]interface{}{"name": "api"},
+		"service.port", 9090,
+		"tags.-1", "y",
+		"limits.rps", 250,
+		"limits", 1,
+	}
+
+	result, err := SetMany(json, pairs...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Pairs apply in order: "service" replaces the earlier port, the later
+	// port writes into the new object, and "limits" replaces limits.rps
+	want := `{"service":{"name":"api","port":9090},"tags":["x","y"],"limits":1}`
+	if string(result) != want {
+		t.Errorf("SetMany = %s, want %s", result, want)
+	}
+
+	sequential := json
+	for i := 0; i < len(pairs); i += 2 {
+		if sequential, err = Set(sequential, pairs[i].(string), pairs[i+1]); err != nil {
+			t.Fatalf("Set(%v) error: %v", pairs[i], err)
+		}
+	}
+	if string(result) != string(sequential) {
+		t.Errorf("SetMany = %s, sequential Set = %s", result, sequential)
+	}
+
+	if _, err := SetMany([]byte(`{"a": undefined}`), "b", 1); err == nil {
+		t.Error("Expected SetMany to reject an undefined placeholder")
+	}
+}
+
+func TestResult_Set(t *testing.T) {
+	json := []byte(`{"user":{"name":"Alice","address":{"city":"NYC"}},"tags":["a"]}`)
+
+	user := Get(json, "user")
+	updated, err := user.Set("address.city", "Paris")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := string(updated.Raw); got != `{"name":"Alice","address":{"city":"Paris"}}` {
+		t.Errorf("Unexpected raw JSON: %s", got)
+	}
+	if user.Get("address.city").String() != "NYC" {
+		t.Error("Original result should be unchanged")
+	}
+
+	updated, err = updated.Set("address.zip", "75001")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.Get("address.zip").String() != "75001" || updated.Get("name").String() != "Alice" {
+		t.Errorf("Chained Set lost data: %s", updated.Raw)
+	}
+
+	tags, err := Get(json, "tags").Set("1", "b")
+	if err != nil || string(tags.Raw) != `["a","b"]` {
+		t.Errorf("Expected [\"a\",\"b\"], got %s (err %v)", tags.Raw, err)
+	}
+
+	if _, err := Get(json, "user.name").Set("x", 1); err != ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch on scalar, got %v", err)
+	}
+}
+
+func TestSet_FloatFormatting(t *testing.T) {
+	tests := []struct {
+		value   float64
+		wantRaw string
+	}{
+		{0.1, "0.1"},
+		{1e-7, "1e-7"},
+		{1234567890.12345, "1234567890.12345"},
+		{1e21, "1e+21"},
+		{-2.5, "-2.5"},
+		{42, "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantRaw, func(t *testing.T) {
+			result, err := Set([]byte(`{"v":0}`), "v", tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := Get(result, "v")
+			if string(got.Raw) != tt.wantRaw {
+				t.Errorf("Expected raw %s, got %s", tt.wantRaw, got.Raw)
+			}
+			if got.Float() != tt.value {
+				t.Errorf("Round-trip mismatch: expected %v, got %v", tt.value, got.Float())
+			}
+		})
+	}
+
+	if _, err := Set([]byte(`{"v":0}`), "v", math.NaN()); err == nil {
+		t.Error("Expected error for NaN")
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	json := []byte(`{"user":{"name":"Alice"},"tags":["a","b"]}`)
+
+	tests := []struct {
+		name    string
+		path    string
+		value   interface{}
+		wantRaw string
+	}{
+		{"replace_string", "user.name", "Bob", `"Bob"`},
+		{"new_number", "user.age", 42, `42`},
+		{"new_object", "user.address", map[string]interface{}{"city": "Paris"}, `{"city":"Paris"}`},
+		{"array_index", "tags.1", "z", `"z"`},
+		{"array_append", "tags.-1", "c", `"c"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, result, err := SetAndGet(json, tt.path, tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(result.Raw) != tt.wantRaw {
+				t.Errorf("Expected result %s, got %s", tt.wantRaw, result.Raw)
+			}
+			if !bytes.Contains(doc, result.Raw) {
+				t.Errorf("Result %s not found in new document %s", result.Raw, doc)
+			}
+		})
+	}
+
+	if _, _, err := SetAndGet(json, "", 1); err == nil {
+		t.Error("Expected error for empty path")
+	}
+}
+
+func TestSet_CompositeGoValues(t *testing.T) {
+	json := []byte(`{"name":"doc","meta":{"v":1}}`)
+
+	t.Run("string_slice", func(t *testing.T) {
+		result, err := Set(json, "tags", []string{"go", "<json>", `{"not":"raw"}`})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if raw := string(Get(result, "tags").Raw); raw != `["go","\u003cjson\u003e","{\"not\":\"raw\"}"]` {
+			t.Errorf("Unexpected tags encoding: %s", raw)
+		}
+		if got := Get(result, "tags.1").String(); got != "<json>" {
+			t.Errorf("tags.1 = %q, want <json>", got)
+		}
+		if Get(result, "tags.2").Type != TypeString {
+			t.Error("Nested JSON-looking strings should stay strings")
+		}
+	})
+
+	t.Run("int_map", func(t *testing.T) {
+		result, err := Set(json, "meta.counts", map[string]int{"b": 2, "a": 1, "c": 3})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if raw := string(Get(result, "meta.counts").Raw); raw != `{"a":1,"b":2,"c":3}` {
+			t.Errorf("Expected sorted keys, got %s", raw)
+		}
+		if Get(result, "meta.counts.b").Int() != 2 || Get(result, "meta.v").Int() != 1 {
+			t.Errorf("Unexpected document: %s", result)
+		}
+	})
+
+	t.Run("nested_map_of_slices", func(t *testing.T) {
+		value := map[string][]int{"odd": {1, 3}, "even": {2, 4}, "none": nil}
+		result, err := Set(json, "groups", value)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if raw := string(Get(result, "groups").Raw); raw != `{"even":[2,4],"none":null,"odd":[1,3]}` {
+			t.Errorf("Unexpected groups encoding: %s", raw)
+		}
+		if Get(result, "groups.odd.1").Int() != 3 {
+			t.Errorf("Expected groups.odd.1 == 3, got %s", Get(result, "groups.odd.1").Raw)
+		}
+	})
+
+	t.Run("mixed_interface_values", func(t *testing.T) {
+		value := []interface{}{1, float32(0.1), "x", nil, true, [2]int{7, 8}, map[string]interface{}{"k": []string{"v"}}}
+		result, err := Set(json, "mixed", value)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if raw := string(Get(result, "mixed").Raw); raw != `[1,0.1,"x",null,true,[7,8],{"k":["v"]}]` {
+			t.Errorf("Unexpected mixed encoding: %s", raw)
+		}
+	})
+
+	t.Run("text_marshaler_elements", func(t *testing.T) {
+		value := map[upperText][]upperText{"k": {"a", "b"}}
+		result, err := Set(json, "upper", value)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if raw := string(Get(result, "upper").Raw); raw != `{"K":["A","B"]}` {
+			t.Errorf("Unexpected upper encoding: %s", raw)
+		}
+	})
+
+	if _, err := Set(json, "bad", []float64{math.Inf(1)}); err == nil {
+		t.Error("Expected error for non-finite element")
+	}
+
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+	if _, err := Set(json, "bad", cyclic); err == nil {
+		t.Error("Expected error for cyclic map")
+	}
+}
+
+// upperText marshals as upper-case text
+type upperText string
+
+func (u upperText) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(u))), nil
+}
+
+func TestSetOptions_CreateArraysForNumericSegments(t *testing.T) {
+	asKeys := &SetOptions{}
+	tests := []struct {
+		name string
+		json string
+		path string
+		opts *SetOptions
+		want string
+	}{
+		{"default_creates_array", `{}`, "a.0.b", nil, `{"a":[{"b":1}]}`},
+		{"default_pads_array", `{}`, "a.2", nil, `{"a":[null,null,1]}`},
+		{"default_nested_arrays", `{"x":true}`, "a.1.b.0", nil, `{"x":true,"a":[null,{"b":[1]}]}`},
+		{"explicit_creates_array", `{}`, "a.0.b", &SetOptions{CreateArraysForNumericSegments: true}, `{"a":[{"b":1}]}`},
+		{"existing_value_replaced", `{"a":[{"b":0}]}`, "a.0.b", nil, `{"a":[{"b":1}]}`},
+		{"as_keys_creates_object", `{}`, "a.0.b", asKeys, `{"a":{"0":{"b":1}}}`},
+		{"as_keys_large_index", `{}`, "a.3000000.b", asKeys, `{"a":{"3000000":{"b":1}}}`},
+		{"as_keys_nested_objects", `{}`, "a.1.b.0", asKeys, `{"a":{"1":{"b":{"0":1}}}}`},
+		{"colon_forces_object_key", `{}`, "a.:0.b", nil, `{"a":{"0":{"b":1}}}`},
+		{"existing_object_keeps_key", `{"a":{}}`, "a.0.b", nil, `{"a":{"0":{"b":1}}}`},
+		{"existing_array_element", `{"a":[{"c":2}]}`, "a.0.b", asKeys, `{"a":[{"c":2,"b":1}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := SetWithOptions([]byte(tt.json), tt.path, 1, tt.opts)
+			if err != nil {
+				t.Fatalf("SetWithOptions() error = %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("SetWithOptions() = %s, want %s", result, tt.want)
+			}
+		})
+	}
+
+	// Set uses the default options
+	result, err := Set([]byte(`{}`), "a.0.b", "x")
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if string(result) != `{"a":[{"b":"x"}]}` {
+		t.Errorf("Set() = %s", result)
+	}
+
+	// Created arrays are bounded rather than padded without limit
+	if _, err := Set([]byte(`{}`), "a.3000000.b", 1); err != ErrArrayIndex {
+		t.Errorf("Set() large index error = %v, want ErrArrayIndex", err)
+	}
+}
+
+func TestSetOptions_RawStringAsJSON(t *testing.T) {
+	payload := `{"x":1}`
+
+	// Default: a string is always stored as a string literal
+	result, err := Set([]byte(`{"a":1}`), "b", payload)
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if string(result) != `{"a":1,"b":"{\"x\":1}"}` {
+		t.Errorf("Set() = %s", result)
+	}
+	if got := Get(result, "b"); got.Type != TypeString {
+		t.Errorf("Expected string literal, got %v", got.Type)
+	}
+
+	tests := []struct {
+		name  string
+		json  string
+		path  string
+		value string
+		want  string
+	}{
+		{"object", `{"a":1}`, "b", payload, `{"a":1,"b":{"x":1}}`},
+		{"replace", `{"a":1}`, "a", `[1, 2]`, `{"a":[1, 2]}`},
+		{"nested", `{}`, "a.b", `true`, `{"a":{"b":true}}`},
+		{"string_json", `{}`, "s", `"hi"`, `{"s":"hi"}`},
+	}
+	opts := &SetOptions{RawStringAsJSON: true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := SetWithOptions([]byte(tt.json), tt.path, tt.value, opts)
+			if err != nil {
+				t.Fatalf("SetWithOptions() error = %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("SetWithOptions() = %s, want %s", result, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{`{"x":}`, `not json`, ``} {
+		result, err := SetWithOptions([]byte(`{"a":1}`), "b", invalid, opts)
+		if err != ErrInvalidJSON {
+			t.Errorf("SetWithOptions(%q) error = %v, want ErrInvalidJSON", invalid, err)
+		}
+		if string(result) != `{"a":1}` {
+			t.Errorf("Expected document unchanged on error, got %s", result)
+		}
+	}
+}
+
+func TestSetStringAppend(t *testing.T) {
+	json := []byte(`{"greeting":"Hello","user":{"tag":"a\"b"},"count":3,"list":["x"]}`)
+
+	tests := []struct {
+		name   string
+		path   string
+		suffix string
+		want   string
+	}{
+		{"simple", "greeting", ", World", "Hello, World"},
+		{"escaped_content", "user.tag", `"c`, `a"b"c`},
+		{"array_element", "list.0", "yz", "xyz"},
+		{"empty_suffix", "greeting", "", "Hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := SetStringAppend(json, tt.path, tt.suffix)
+			if err != nil {
+				t.Fatalf("SetStringAppend() error = %v", err)
+			}
+			if got := Get(result, tt.path).String(); got != tt.want {
+				t.Errorf("Get(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+			if !Valid(result) {
+				t.Errorf("Invalid JSON result: %s", result)
+			}
+		})
+	}
+
+	if _, err := SetStringAppend(json, "count", "x"); err != ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch for number target, got %v", err)
+	}
+	if _, err := SetStringAppend(json, "list", "x"); err != ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch for array target, got %v", err)
+	}
+	if _, err := SetStringAppend(json, "missing", "x"); err != ErrPathNotFound {
+		t.Errorf("Expected ErrPathNotFound for missing target, got %v", err)
+	}
+}
+
+func TestSetWithQuerySegment(t *testing.T) {
+	json := []byte(`{"users":[{"id":1,"active":false},{"id":2,"active":false},{"id":2,"active":false}]}`)
+
+	result, err := Set(json, "users.#(id==2).active", true)
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got := Get(result, "users.#.active").Raw; string(got) != `[false,true,false]` {
+		t.Errorf("users.#.active = %s, want only the first match changed", got)
+	}
+
+	result, err = Set(json, `users.#(id==1)`, map[string]interface{}{"id": 1, "active": true})
+	if err != nil {
+		t.Fatalf("Set() on matched element error = %v", err)
+	}
+	if !Get(result, "users.0.active").Bool() {
+		t.Errorf("expected users.0 to be replaced, got %s", result)
+	}
+
+	if _, err := Set(json, "users.#(id==9).active", true); err != ErrPathNotFound {
+		t.Errorf("Expected ErrPathNotFound when nothing matches, got %v", err)
+	}
+	if _, err := Set(json, "users.#(id==2)#.active", true); err != ErrInvalidPath {
+		t.Errorf("Expected ErrInvalidPath for an all-match query, got %v", err)
+	}
+	if _, err := Set(json, "users.0.#(id==2).x", true); err != ErrPathNotFound {
+		t.Errorf("Expected ErrPathNotFound when the query target is not an array, got %v", err)
+	}
+}
+
+func TestSetPreservesNumberFormatting(t *testing.T) {
+	doc := []byte(`{"a":1.2300,"b":1e5,"c":{"x":-0.0,"y":1E+02},"arr":[1.50,2e-3],"t":"v"}`)
+	tokens := []string{`1.2300`, `1e5`, `-0.0`, `1E+02`, `1.50`, `2e-3`}
+
+	paths := []string{"t", "c.x", "c.new", "new", "arr.-1", "arr.5", "x.y.z", "arr.#(==2e-3)"}
+	for _, path := range paths {
+		out, err := Set(doc, path, "z")
+		if err != nil {
+			t.Fatalf("Set(%q) error = %v", path, err)
+		}
+		for _, tok := range tokens {
+			if path == "c.x" && tok == `-0.0` || path == "arr.#(==2e-3)" && tok == `2e-3` {
+				continue // the token that was replaced
+			}
+			if !bytes.Contains(out, []byte(tok)) {
+				t.Errorf("Set(%q) lost %s: %s", path, tok, out)
+			}
+		}
+	}
+
+	out, err := SetWithOptions(doc, "c", map[string]interface{}{"z": 1}, &SetOptions{MergeObjects: true})
+	if err != nil {
+		t.Fatalf("SetWithOptions(MergeObjects) error = %v", err)
+	}
+	for _, tok := range tokens {
+		if !bytes.Contains(out, []byte(tok)) {
+			t.Errorf("merge lost %s: %s", tok, out)
+		}
+	}
+
+	// The public hook keeps encoding/json's default number decoding
+	var v interface{}
+	if err := JSON.Unmarshal([]byte(`1.50`), &v); err != nil || v != 1.5 {
+		t.Errorf("JSON.Unmarshal = %#v, %v; want float64 1.5", v, err)
+	}
+}
+
+func TestSetRaw(t *testing.T) {
+	doc := []byte(`{"a":1,"arr":[1,2],"o":{"x":1}}`)
+	raw := []byte(`{"n":1.50,"s":"x"}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"a", `{"a":{"n":1.50,"s":"x"},"arr":[1,2],"o":{"x":1}}`},
+		{"o.y", `{"a":1,"arr":[1,2],"o":{"x":1,"y":{"n":1.50,"s":"x"}}}`},
+		{"arr.0", `{"a":1,"arr":[{"n":1.50,"s":"x"},2],"o":{"x":1}}`},
+		{"x.y", `{"a":1,"arr":[1,2],"o":{"x":1},"x":{"y":{"n":1.50,"s":"x"}}}`},
+	}
+	for _, tt := range tests {
+		got, err := SetRaw(doc, tt.path, raw)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("SetRaw(%q) = %s, %v; want %s", tt.path, got, err, tt.want)
+		}
+	}
+
+	// Appends go through the slower rebuild path and must not re-encode
+	got, err := SetRaw(doc, "arr.-1", []byte(`"s"`))
+	if err != nil || Get(got, "arr.2").String() != "s" || Get(got, "arr.#").Int() != 3 {
+		t.Errorf("SetRaw append = %s, %v", got, err)
+	}
+
+	// Set on the same bytes as a string encodes them
+	if got, _ := Set(doc, "a", string(raw)); Get(got, "a").Type != TypeString {
+		t.Errorf("Set with a string should store a string, got %s", got)
+	}
+
+	for _, empty := range [][]byte{nil, []byte(" ")} {
+		if _, err := SetRaw(doc, "a", empty); err != ErrInvalidJSON {
+			t.Errorf("SetRaw(%q) error = %v, want ErrInvalidJSON", empty, err)
+		}
+	}
+}
@@ -62,3 +62,223 @@ func shouldEscapePathChar(c byte) bool {
 	}
 	return false
 }
+
+// PathSyntax identifies a path dialect for ConvertPath.
+type PathSyntax int
+
+const (
+	// PathDotted is the nqjson dot notation, e.g. users.0.name
+	PathDotted PathSyntax = iota
+	// PathJSONPointer is RFC 6901 JSON Pointer, e.g. /users/0/name
+	PathJSONPointer
+	// PathJSONPath is bracket/dot JSONPath, e.g. $.users[0].name
+	PathJSONPath
+)
+
+// pathStep is one literal step of a path: an object key or an array index
+type pathStep struct {
+	key   string
+	index bool
+}
+
+// ConvertPath translates a literal path between syntaxes. Only keys and array
+// indexes can be converted; wildcards, queries and modifiers return
+// ErrInvalidPath. All-digit segments are treated as array indexes unless the
+// source syntax marks them as keys (":0" in dot notation, ['0'] in JSONPath).
+//
+// Example: ConvertPath("users.0.first\\.name", PathDotted, PathJSONPointer)
+// returns "/users/0/first.name".
+func ConvertPath(path string, from, to PathSyntax) (string, error) {
+	var steps []pathStep
+	var err error
+	switch from {
+	case PathDotted:
+		steps, err = parseDottedSteps(path)
+	case PathJSONPointer:
+		steps, err = parsePointerSteps(path)
+	case PathJSONPath:
+		steps, err = parseJSONPathSteps(path)
+	default:
+		return "", ErrInvalidPath
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	switch to {
+	case PathDotted:
+		for i, step := range steps {
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			switch {
+			case step.key == "":
+				return "", ErrInvalidPath // empty keys have no dotted form
+			case step.index:
+				b.WriteString(step.key)
+			case isAllDigitsGet(step.key):
+				b.WriteString(":" + step.key)
+			case step.key[0] == ':':
+				b.WriteString(`\:` + EscapePathSegment(step.key[1:]))
+			default:
+				b.WriteString(EscapePathSegment(step.key))
+			}
+		}
+	case PathJSONPointer:
+		for _, step := range steps {
+			b.WriteByte('/')
+			b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(step.key))
+		}
+	case PathJSONPath:
+		b.WriteByte('$')
+		for _, step := range steps {
+			switch {
+			case step.index:
+				b.WriteString("[" + step.key + "]")
+			case isJSONPathIdent(step.key):
+				b.WriteString("." + step.key)
+			default:
+				b.WriteString("['" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(step.key) + "']")
+			}
+		}
+	default:
+		return "", ErrInvalidPath
+	}
+	return b.String(), nil
+}
+
+func parseDottedSteps(path string) ([]pathStep, error) {
+	if path == "" {
+		return nil, nil
+	}
+	segs := splitPathGet(path)
+	steps := make([]pathStep, 0, len(segs))
+	for _, seg := range segs {
+		if seg == "" {
+			return nil, ErrInvalidPath
+		}
+		for i := 0; i < len(seg); i++ {
+			if seg[i] == '\\' {
+				i++
+			} else if shouldEscapePathChar(seg[i]) && !(i == 0 && seg[i] == ':') {
+				return nil, ErrInvalidPath
+			}
+		}
+
+		key := unescapePathGet(seg)
+		switch {
+		case hasColonPrefixGet(seg):
+			steps = append(steps, pathStep{key: stripColonPrefixGet(key)})
+		case isAllDigitsGet(key):
+			steps = append(steps, pathStep{key: key, index: true})
+		default:
+			steps = append(steps, pathStep{key: key})
+		}
+	}
+	return steps, nil
+}
+
+func parsePointerSteps(path string) ([]pathStep, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if path[0] != '/' {
+		return nil, ErrInvalidPath
+	}
+
+	segs := strings.Split(path[1:], "/")
+	steps := make([]pathStep, 0, len(segs))
+	for _, seg := range segs {
+		for i := 0; i < len(seg); i++ {
+			if seg[i] == '~' && (i+1 >= len(seg) || (seg[i+1] != '0' && seg[i+1] != '1')) {
+				return nil, ErrInvalidPath
+			}
+		}
+		key := strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+		isIndex := isAllDigitsGet(key) && (key == "0" || key[0] != '0')
+		steps = append(steps, pathStep{key: key, index: isIndex})
+	}
+	return steps, nil
+}
+
+func parseJSONPathSteps(path string) ([]pathStep, error) {
+	if path == "" || path[0] != '$' {
+		return nil, ErrInvalidPath
+	}
+
+	var steps []pathStep
+	for i := 1; i < len(path); {
+		switch path[i] {
+		case '.':
+			j := i + 1
+			for j < len(path) && path[j] != '.' && path[j] != '[' {
+				j++
+			}
+			name := path[i+1 : j]
+			if !isJSONPathIdent(name) {
+				return nil, ErrInvalidPath
+			}
+			steps = append(steps, pathStep{key: name})
+			i = j
+		case '[':
+			step, next, ok := parseJSONPathBracket(path, i)
+			if !ok {
+				return nil, ErrInvalidPath
+			}
+			steps = append(steps, step)
+			i = next
+		default:
+			return nil, ErrInvalidPath
+		}
+	}
+	return steps, nil
+}
+
+// parseJSONPathBracket parses [n], ['key'] or ["key"] starting at path[i]
+func parseJSONPathBracket(path string, i int) (pathStep, int, bool) {
+	if i+1 >= len(path) {
+		return pathStep{}, 0, false
+	}
+
+	quote := path[i+1]
+	if quote != '\'' && quote != '"' {
+		end := strings.IndexByte(path[i:], ']')
+		if end < 0 || !isAllDigitsGet(path[i+1:i+end]) {
+			return pathStep{}, 0, false
+		}
+		return pathStep{key: path[i+1 : i+end], index: true}, i + end + 1, true
+	}
+
+	var key strings.Builder
+	for j := i + 2; j < len(path); j++ {
+		switch c := path[j]; {
+		case c == '\\' && j+1 < len(path):
+			j++
+			key.WriteByte(path[j])
+		case c == quote:
+			if j+1 >= len(path) || path[j+1] != ']' {
+				return pathStep{}, 0, false
+			}
+			return pathStep{key: key.String()}, j + 2, true
+		default:
+			key.WriteByte(c)
+		}
+	}
+	return pathStep{}, 0, false
+}
+
+// isJSONPathIdent reports whether s can be written as .name in JSONPath
+func isJSONPathIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}